@@ -4,20 +4,29 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	"github.com/newrelic/nri-vsphere/internal/client"
 	"github.com/newrelic/nri-vsphere/internal/collect"
 	"github.com/newrelic/nri-vsphere/internal/config"
 	"github.com/newrelic/nri-vsphere/internal/performance"
 	"github.com/newrelic/nri-vsphere/internal/process"
+	"github.com/newrelic/nri-vsphere/internal/scheduler"
 	"github.com/newrelic/nri-vsphere/internal/tag"
 
+	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
 	"github.com/vmware/govmomi/view"
 
@@ -56,16 +65,65 @@ func main() {
 
 	checkAndSanitizeConfig(cfg)
 
-	cfg.VMWareClient, err = client.New(cfg.Args.URL, cfg.Args.User, cfg.Args.Pass, cfg.Args.ValidateSSL)
+	if cfg.DaemonModeEnabled() {
+		runDaemon(cfg)
+		return
+	}
+
+	if cfg.MultiEndpointEnabled() {
+		runMultiEndpointIntegration(cfg)
+		return
+	}
+
+	closeClients, err := setupClients(cfg)
 	if err != nil {
-		cfg.Logrus.WithError(err).Fatal("failed to create client")
+		cfg.Logrus.WithError(err).Fatal("failed to initialize integration clients")
 	}
-	defer func() {
-		err := client.Logout(cfg.VMWareClient)
-		if err != nil {
-			cfg.Logrus.WithError(err).Error("error while logging out client")
+	defer closeClients()
+
+	runIntegration(cfg)
+
+}
+
+// setupClients logs into the endpoint configured and creates the clients and collectors needed to fetch data.
+// The returned func logs out the clients and must be called once the data is collected.
+func setupClients(cfg *config.Config) (func(), error) {
+	var closers []func()
+	closeClients := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
 		}
-	}()
+	}
+
+	var sessions *client.SessionStore
+	if cfg.SessionReuseEnabled() {
+		sessions = newSessionStore(cfg)
+	}
+
+	var err error
+	if sessions != nil {
+		cfg.VMWareClient, err = client.NewWithSessionStore(cfg.Args.URL, cfg.Args.User, cfg.Args.Pass, cfg.Args.ValidateSSL, sessions)
+	} else {
+		cfg.VMWareClient, err = client.New(cfg.Args.URL, cfg.Args.User, cfg.Args.Pass, cfg.Args.ValidateSSL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %v", err)
+	}
+	// stored sessions are kept open to be reused in the next execution
+	if sessions == nil {
+		closers = append(closers, func() {
+			err := client.Logout(cfg.VMWareClient)
+			if err != nil {
+				cfg.Logrus.WithError(err).Error("error while logging out client")
+			}
+		})
+	}
+
+	if cfg.DaemonModeEnabled() {
+		// stopped explicitly since stored sessions are not logged out
+		keepAlive := client.KeepAlive(cfg.VMWareClient, sessionKeepAlive)
+		closers = append(closers, keepAlive.Stop)
+	}
 
 	cfg.IsVcenterAPIType = cfg.VMWareClient.ServiceContent.About.ApiType == "VirtualCenter"
 	cfg.Logrus.Debugf("API type:%s", cfg.VMWareClient.ServiceContent.About.ApiType)
@@ -73,6 +131,9 @@ func main() {
 	if !cfg.IsVcenterAPIType && cfg.Args.EnableVsphereEvents {
 		cfg.Logrus.Warn("It is not possible to fetch events from the vCenter if the integration is pointing to an host")
 	}
+	if !cfg.IsVcenterAPIType && cfg.Args.EnableVsphereTasks {
+		cfg.Logrus.Warn("It is not possible to fetch tasks from the vCenter if the integration is pointing to an host")
+	}
 	if !cfg.IsVcenterAPIType && cfg.Args.EnableVsphereTags {
 		cfg.Logrus.Warn("It is not possible to fetch Tags from the vCenter if the integration is pointing to an host")
 	}
@@ -80,16 +141,28 @@ func main() {
 	cfg.ViewManager = view.NewManager(cfg.VMWareClient.Client)
 
 	if cfg.TagCollectionEnabled() {
-		restClient, err := client.NewRest(cfg.VMWareClient, cfg.Args.User, cfg.Args.Pass)
+		var restClient *rest.Client
+		if sessions != nil {
+			restClient, err = client.NewRestWithSessionStore(cfg.VMWareClient, cfg.Args.User, cfg.Args.Pass, sessions)
+		} else {
+			restClient, err = client.NewRest(cfg.VMWareClient, cfg.Args.User, cfg.Args.Pass)
+		}
 		if err != nil {
-			cfg.Logrus.WithError(err).Fatal("failed to create client rest")
+			closeClients()
+			return nil, fmt.Errorf("failed to create client rest: %v", err)
+		}
+		if cfg.DaemonModeEnabled() {
+			keepAlive := client.KeepAliveRest(restClient, sessionKeepAlive)
+			closers = append(closers, keepAlive.Stop)
+		}
+		if sessions == nil {
+			closers = append(closers, func() {
+				err := client.LogoutRest(restClient)
+				if err != nil {
+					cfg.Logrus.WithError(err).Error("error while logging out RestClient")
+				}
+			})
 		}
-		defer func() {
-			err := client.LogoutRest(restClient)
-			if err != nil {
-				cfg.Logrus.WithError(err).Error("error while logging out RestClient")
-			}
-		}()
 
 		tm := tags.NewManager(restClient)
 		tagCollector := tag.NewCollector(tm, cfg.Logrus)
@@ -104,24 +177,40 @@ func main() {
 			cfg.Args.LogAvailableCounters, cfg.Args.PerfLevel, cfg.Args.BatchSizePerfEntities,
 			cfg.Args.BatchSizePerfMetrics)
 		if err != nil {
-			cfg.Logrus.WithError(err).Fatal("failed to create performance collector")
+			closeClients()
+			return nil, fmt.Errorf("failed to create performance collector: %v", err)
 		}
 		cfg.PerfCollector = perfCollector
 	}
 
-	runIntegration(cfg)
+	return closeClients, nil
+}
 
+// newSessionStore returns the store of the sessions of the endpoint. Each endpoint and user gets its own file in
+// the persist directory, and an in memory store is used if the file can not be created.
+func newSessionStore(cfg *config.Config) *client.SessionStore {
+	id := sha256.Sum256([]byte(cfg.Args.URL + "\x00" + cfg.Args.User))
+	path := persist.DefaultPath(fmt.Sprintf("%s_sessions_%x", cfg.IntegrationName, id[:8]))
+	store, err := persist.NewFileStore(path, cfg.Logrus, 24*time.Hour)
+	if err != nil {
+		cfg.Logrus.WithError(err).Warn("could not create sessions store, sessions will not be reused across executions")
+		store = persist.NewInMemoryStore()
+	}
+	return client.NewSessionStore(store, cfg.Logrus, cfg.Args.URL, cfg.Args.User, cfg.Args.Pass)
 }
 
 func checkAndSanitizeConfig(cfg *config.Config) {
-	if cfg.Args.URL == "" {
-		cfg.Logrus.Fatal("missing argument `url`, please check if URL has been supplied in the config file")
-	}
-	if cfg.Args.User == "" {
-		cfg.Logrus.Fatal("missing argument `user`, please check if username has been supplied in the config file")
-	}
-	if cfg.Args.Pass == "" {
-		cfg.Logrus.Fatal("missing argument `pass`, please check if password has been supplied")
+	// connection data is validated per endpoint when loading the endpoints file
+	if !cfg.MultiEndpointEnabled() {
+		if cfg.Args.URL == "" {
+			cfg.Logrus.Fatal("missing argument `url`, please check if URL has been supplied in the config file")
+		}
+		if cfg.Args.User == "" {
+			cfg.Logrus.Fatal("missing argument `user`, please check if username has been supplied in the config file")
+		}
+		if cfg.Args.Pass == "" {
+			cfg.Logrus.Fatal("missing argument `pass`, please check if password has been supplied")
+		}
 	}
 
 	if cfg.Args.EnableVspherePerfMetrics && cfg.Args.PerfMetricFile == "" {
@@ -148,17 +237,12 @@ func setupLogger(config *config.Config) {
 }
 
 func runIntegration(config *config.Config) {
-	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("before collecting data")
-	err := collect.CollectData(config)
+	err := collectAndProcess(config)
 	if err != nil {
 		config.Logrus.Error(err)
 		return
 	}
 
-	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("before processing data")
-	process.ProcessData(config)
-	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("after processing data")
-
 	err = config.Integration.Publish()
 	if err != nil {
 		config.Logrus.WithError(err).Fatal("failed to publish")
@@ -166,6 +250,213 @@ func runIntegration(config *config.Config) {
 
 }
 
+// runMultiEndpointIntegration collects concurrently all the endpoints listed in the endpoints file and publishes
+// the data of all of them at once. An endpoint failing does not prevent the others from being reported.
+func runMultiEndpointIntegration(cfg *config.Config) {
+	endpoints, err := config.LoadEndpoints(cfg.Args.EndpointsFile)
+	if err != nil {
+		cfg.Logrus.WithError(err).Fatal("failed to load endpoints")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(endpoints))
+	for _, e := range endpoints {
+		go func(ec *config.Config) {
+			defer wg.Done()
+			logger := ec.Logrus.WithField("endpoint", ec.EndpointID)
+
+			closeClients, err := setupClients(ec)
+			if err != nil {
+				logger.WithError(err).Error("failed to initialize endpoint clients, skipping endpoint")
+				return
+			}
+			defer closeClients()
+
+			err = collectAndProcess(ec)
+			if err != nil {
+				logger.WithError(err).Error("failed to collect endpoint data")
+			}
+		}(cfg.ForEndpoint(e))
+	}
+	wg.Wait()
+
+	err = cfg.Integration.Publish()
+	if err != nil {
+		cfg.Logrus.WithError(err).Fatal("failed to publish")
+	}
+}
+
+// sessionKeepAlive is the idle time after which sessions are refreshed in daemon mode
+const sessionKeepAlive = 5 * time.Minute
+
+// names of the tasks run in daemon mode
+const (
+	inventoryTask = "inventory"
+	tagsTask      = "tags"
+	eventsTask    = "events"
+	perfTask      = "perf"
+)
+
+// runDaemon keeps the integration running and collects inventory, tags, events and performance metrics of each
+// endpoint on their own intervals. Sessions and inventories are kept between collections and the data is
+// published after each one. Endpoints failing are set up again in the next collection.
+func runDaemon(cfg *config.Config) {
+	s := scheduler.New(
+		scheduler.Task{Name: inventoryTask, Interval: parseInterval(cfg, "inventory_interval", cfg.Args.InventoryInterval)},
+		scheduler.Task{Name: tagsTask, Interval: parseInterval(cfg, "tags_interval", cfg.Args.TagsInterval)},
+		scheduler.Task{Name: eventsTask, Interval: parseInterval(cfg, "events_interval", cfg.Args.EventsInterval)},
+		scheduler.Task{Name: perfTask, Interval: parseInterval(cfg, "perf_interval", cfg.Args.PerfInterval)},
+	)
+
+	var endpoints []*daemonEndpoint
+	if cfg.MultiEndpointEnabled() {
+		list, err := config.LoadEndpoints(cfg.Args.EndpointsFile)
+		if err != nil {
+			cfg.Logrus.WithError(err).Fatal("failed to load endpoints")
+		}
+		for _, e := range list {
+			endpoints = append(endpoints, &daemonEndpoint{config: cfg.ForEndpoint(e)})
+		}
+	} else {
+		endpoints = append(endpoints, &daemonEndpoint{config: cfg})
+	}
+	defer func() {
+		for _, e := range endpoints {
+			e.close()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s.Run(ctx, func(tasks []string) {
+		cfg.Logrus.WithField("tasks", tasks).Debug("running scheduled tasks")
+
+		var wg sync.WaitGroup
+		wg.Add(len(endpoints))
+		for _, e := range endpoints {
+			go func(e *daemonEndpoint) {
+				defer wg.Done()
+				err := e.run(tasks)
+				if err != nil {
+					e.config.Logrus.WithError(err).WithField("endpoint", e.config.EndpointID).Error("failed to collect endpoint data")
+				}
+			}(e)
+		}
+		wg.Wait()
+
+		err := cfg.Integration.Publish()
+		if err != nil {
+			cfg.Logrus.WithError(err).Error("failed to publish")
+		}
+	})
+	cfg.Logrus.Debug("integration stopped")
+}
+
+func parseInterval(cfg *config.Config, name string, value string) time.Duration {
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		cfg.Logrus.WithField(name, value).Fatal("invalid interval")
+	}
+	return interval
+}
+
+// daemonEndpoint holds the clients and the inventory of an endpoint between collections
+type daemonEndpoint struct {
+	config       *config.Config
+	inventory    *collect.Inventory
+	closeClients func()
+}
+
+// run executes the given tasks and processes the data they collected: the samples of every object after the
+// inventory or perf metrics are collected, and the events after they are. Tags are reported along with the
+// following samples. Endpoints not set up yet run every task but tags, that are fetched along with the objects
+// of the inventory.
+func (e *daemonEndpoint) run(tasks []string) error {
+	cfg := e.config
+
+	if e.inventory == nil {
+		closeClients, err := setupClients(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize endpoint clients: %v", err)
+		}
+		inv, err := collect.NewInventory(cfg)
+		if err != nil {
+			closeClients()
+			return fmt.Errorf("failed to initialize inventory: %v", err)
+		}
+		e.inventory = inv
+		e.closeClients = closeClients
+		tasks = []string{inventoryTask, eventsTask, perfTask}
+	}
+
+	var samples, events bool
+	cfg.Logrus.WithField("seconds", cfg.Uptime().Seconds()).Debug("before collecting data")
+	for _, task := range tasks {
+		switch task {
+		case inventoryTask:
+			err := e.inventory.Sync(!cfg.IncrementalInventoryEnabled())
+			if err != nil {
+				// the session might not be valid anymore, clients and inventory are created again in the next run
+				e.close()
+				return err
+			}
+			samples = true
+		case tagsTask:
+			if cfg.TagCollectionEnabled() {
+				e.inventory.CollectTags()
+			}
+		case eventsTask:
+			if cfg.EventCollectionEnabled() || cfg.TaskCollectionEnabled() {
+				e.inventory.CollectEvents()
+				events = true
+			}
+		case perfTask:
+			if cfg.PerfMetricsCollectionEnabled() {
+				e.inventory.CollectPerfMetrics()
+				samples = true
+			}
+		}
+	}
+
+	cfg.Logrus.WithField("seconds", cfg.Uptime().Seconds()).Debug("before processing data")
+	if samples {
+		process.ProcessSamples(cfg)
+	}
+	if events {
+		process.ProcessEvents(cfg)
+	}
+	cfg.Logrus.WithField("seconds", cfg.Uptime().Seconds()).Debug("after processing data")
+
+	// events and perf metrics are reported only once
+	e.inventory.ClearEvents()
+	e.inventory.ClearPerfMetrics()
+	return nil
+}
+
+func (e *daemonEndpoint) close() {
+	if e.inventory == nil {
+		return
+	}
+	e.inventory.Destroy()
+	e.closeClients()
+	e.inventory = nil
+	e.closeClients = nil
+}
+
+func collectAndProcess(config *config.Config) error {
+	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("before collecting data")
+	err := collect.CollectData(config)
+	if err != nil {
+		return err
+	}
+
+	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("before processing data")
+	process.ProcessData(config)
+	config.Logrus.WithField("seconds", config.Uptime().Seconds()).Debug("after processing data")
+	return nil
+}
+
 func infraIntegration(config *config.Config) error {
 	var err error
 	config.Hostname, err = os.Hostname() // set hostname
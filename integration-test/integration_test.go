@@ -71,6 +71,7 @@ func TestIntegrationPerformanceMetrics(t *testing.T) {
 		"-enable_vsphere_perf_metrics",
 		"-perf_metric_file", "../vsphere-performance.metrics",
 		"-perf_level", "4",
+		"-enable_perf_instance_samples",
 	})
 	//Notice that stdErr contains as well normal logs of the integration
 	require.NotNil(t, stderr, "unexpected stderr")
@@ -12,9 +12,16 @@ type Cache struct {
 	store        persist.Storer
 }
 
+// keySuffix is appended to the resource name to store the key of the last item read along with its timestamp
+const keySuffix = "_key"
+
 type CacheInterface interface {
 	ReadTimestampCache() (time.Time, error)
 	WriteTimestampCache(lastTimestamp time.Time) error
+	ReadKeyCache() (int32, error)
+	WriteKeyCache(lastKey int32) error
+	ReadListCache(name string) ([]string, error)
+	WriteListCache(name string, values []string) error
 }
 
 func NewCache(resourceName string, store persist.Storer) *Cache {
@@ -37,3 +44,33 @@ func (c *Cache) WriteTimestampCache(lastTimestamp time.Time) error {
 	c.store.Set(c.resourceName, lastTimestamp.UnixNano())
 	return c.store.Save()
 }
+
+func (c *Cache) ReadKeyCache() (int32, error) {
+	var key int32
+	_, err := c.store.Get(c.resourceName+keySuffix, &key)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading cache file: %s, ", err.Error())
+	}
+	return key, err
+}
+
+func (c *Cache) WriteKeyCache(lastKey int32) error {
+	c.store.Set(c.resourceName+keySuffix, lastKey)
+	return c.store.Save()
+}
+
+// ReadListCache reads the list of values stored under the given name along with the timestamp, such as the keys of
+// the last items read when they are not numeric
+func (c *Cache) ReadListCache(name string) ([]string, error) {
+	var values []string
+	_, err := c.store.Get(c.resourceName+"_"+name, &values)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading cache file: %s, ", err.Error())
+	}
+	return values, err
+}
+
+func (c *Cache) WriteListCache(name string, values []string) error {
+	c.store.Set(c.resourceName+"_"+name, values)
+	return c.store.Save()
+}
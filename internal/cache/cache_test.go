@@ -29,3 +29,47 @@ func Test_Cache_SavesCorrectTimestamp(t *testing.T) {
 	assert.True(t, expected.Equal(actual))
 	assert.Equal(t, expected.UnixNano(), actual.UnixNano())
 }
+
+func Test_Cache_SavesCorrectKey(t *testing.T) {
+
+	datacenter := "my-datacenter"
+	store := persist.NewInMemoryStore()
+
+	//given
+	c := NewCache(datacenter, store)
+	_, err := c.ReadKeyCache()
+	assert.Error(t, err)
+
+	//when
+	err = c.WriteTimestampCache(time.Now())
+	assert.NoError(t, err)
+	err = c.WriteKeyCache(4242)
+	assert.NoError(t, err)
+
+	//then
+	actual, err := c.ReadKeyCache()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4242), actual)
+}
+
+func Test_Cache_SavesCorrectList(t *testing.T) {
+
+	datacenter := "my-datacenter"
+	store := persist.NewInMemoryStore()
+
+	//given
+	c := NewCache(datacenter, store)
+	_, err := c.ReadListCache("keys")
+	assert.Error(t, err)
+
+	//when
+	err = c.WriteListCache("keys", []string{"task-1", "task-2"})
+	assert.NoError(t, err)
+
+	//then
+	actual, err := c.ReadListCache("keys")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"task-1", "task-2"}, actual)
+	_, err = NewCache("other-datacenter", store).ReadListCache("keys")
+	assert.Error(t, err)
+}
@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session/keepalive"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25/soap"
 )
@@ -67,6 +69,25 @@ func NewRest(clientvim25 *govmomi.Client, vmUsername string, vmPassword string)
 	return re, nil
 }
 
+// KeepAlive keeps the session of the client alive sending a request every time it is idle for the given interval.
+// It stops when the client logs out or when the handler returned is stopped, which is needed for sessions kept open
+// to be reused.
+func KeepAlive(client *govmomi.Client, idle time.Duration) *keepalive.HandlerSOAP {
+	h := keepalive.NewHandlerSOAP(client.Client.RoundTripper, idle, nil)
+	client.Client.RoundTripper = h
+	h.Start()
+	return h
+}
+
+// KeepAliveRest keeps the session of the rest client alive sending a request every time it is idle for the given
+// interval. It stops when the client logs out or when the handler returned is stopped.
+func KeepAliveRest(restClient *rest.Client, idle time.Duration) *keepalive.HandlerREST {
+	h := keepalive.NewHandlerREST(restClient, idle, nil)
+	restClient.Transport = h
+	h.Start()
+	return h
+}
+
 func setCredentials(u *url.URL, un string, pw string) {
 	// Override username if provided
 	if un != "" {
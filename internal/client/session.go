@@ -0,0 +1,205 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const (
+	soapSessionKey = "vim25"
+	restSessionKey = "vapi"
+
+	// saltSize is the size of the random salt stored with each encrypted session
+	saltSize = 16
+	// keyIterations is the PBKDF2 work factor used to derive the encryption key from the credentials
+	keyIterations = 210000
+)
+
+// SessionStore persists the sessions of the clients so they can be reused across executions.
+// Sessions are encrypted with a key derived from the endpoint credentials with PBKDF2 and a random salt
+// stored next to the ciphertext. A stored session grants the same access as the credentials until it expires,
+// so the persist directory must be protected as the credentials are.
+type SessionStore struct {
+	store  persist.Storer
+	logger *logrus.Logger
+	secret string
+}
+
+// NewSessionStore creates a SessionStore saving the sessions of the given endpoint and user in store
+func NewSessionStore(store persist.Storer, logger *logrus.Logger, vmURL string, vmUsername string, vmPassword string) *SessionStore {
+	return &SessionStore{
+		store:  store,
+		logger: logger,
+		secret: vmURL + "\x00" + vmUsername + "\x00" + vmPassword,
+	}
+}
+
+// load returns the session stored with the given key, if any
+func (s *SessionStore) load(key string) (string, bool) {
+	var encrypted string
+	_, err := s.store.Get(key, &encrypted)
+	if err != nil {
+		return "", false
+	}
+	session, err := s.decrypt(encrypted)
+	if err != nil {
+		s.logger.WithError(err).WithField("session", key).Debug("ignoring stored session")
+		return "", false
+	}
+	return session, true
+}
+
+// save stores the session with the given key. Failures are only logged since the session is still valid.
+func (s *SessionStore) save(key string, session string) {
+	encrypted, err := s.encrypt(session)
+	if err != nil {
+		s.logger.WithError(err).WithField("session", key).Warn("failed to encrypt session")
+		return
+	}
+	s.store.Set(key, encrypted)
+	err = s.store.Save()
+	if err != nil {
+		s.logger.WithError(err).WithField("session", key).Warn("failed to save session")
+	}
+}
+
+// encrypt returns the session sealed as base64 of salt, nonce and ciphertext
+func (s *SessionStore) encrypt(plain string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	gcm, err := s.cipher(salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(append(salt, nonce...), nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *SessionStore) decrypt(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < saltSize {
+		return "", fmt.Errorf("stored session is too short")
+	}
+	gcm, err := s.cipher(sealed[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	sealed = sealed[saltSize:]
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("stored session is too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (s *SessionStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.secret, salt, keyIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewWithSessionStore creates a new VMWare client reusing the session stored if it is still active.
+// Otherwise it logs in and stores the new session.
+func NewWithSessionStore(vmURL string, vmUsername string, vmPassword string, ValidateSSL bool, store *SessionStore) (*govmomi.Client, error) {
+	ctx := context.Background()
+
+	urlParsed, err := soap.ParseURL(vmURL)
+	if err != nil {
+		return nil, err
+	}
+	setCredentials(urlParsed, vmUsername, vmPassword)
+
+	soapClient := soap.NewClient(urlParsed, !ValidateSSL)
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return nil, err
+	}
+	c := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+
+	if cookie, ok := store.load(soapSessionKey); ok {
+		soapClient.Jar.SetCookies(soapClient.URL(), []*http.Cookie{{Name: soap.SessionCookieName, Value: cookie}})
+		userSession, err := c.SessionManager.UserSession(ctx)
+		if err == nil && userSession != nil {
+			store.logger.Debug("reusing stored session")
+			return c, nil
+		}
+		store.logger.Debug("stored session is not active anymore, logging in")
+	}
+
+	err = c.Login(ctx, urlParsed.User)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range soapClient.Jar.Cookies(soapClient.URL()) {
+		if cookie.Name == soap.SessionCookieName {
+			store.save(soapSessionKey, cookie.Value)
+		}
+	}
+	return c, nil
+}
+
+// NewRestWithSessionStore creates a new VMWare rest client reusing the session stored if it is still active.
+// Otherwise it logs in and stores the new session.
+func NewRestWithSessionStore(clientvim25 *govmomi.Client, vmUsername string, vmPassword string, store *SessionStore) (*rest.Client, error) {
+	ctx := context.Background()
+	re := rest.NewClient(clientvim25.Client)
+
+	if id, ok := store.load(restSessionKey); ok {
+		re.SessionID(id)
+		s, err := re.Session(ctx)
+		if err == nil && s != nil {
+			store.logger.Debug("reusing stored rest session")
+			return re, nil
+		}
+		re.SessionID("")
+		store.logger.Debug("stored rest session is not active anymore, logging in")
+	}
+
+	err := re.Login(ctx, url.UserPassword(vmUsername, vmPassword))
+	if err != nil {
+		return nil, fmt.Errorf("fail to login in rest client:%v", err)
+	}
+	store.save(restSessionKey, re.SessionID())
+	return re, nil
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func TestSessionStore_EncryptsSessions(t *testing.T) {
+	store := persist.NewInMemoryStore()
+	s := NewSessionStore(store, logrus.StandardLogger(), "https://vcenter/sdk", "user", "pass")
+
+	s.save(soapSessionKey, "session-cookie")
+
+	var stored string
+	_, err := store.Get(soapSessionKey, &stored)
+	require.NoError(t, err)
+	assert.NotContains(t, stored, "session-cookie")
+
+	session, ok := s.load(soapSessionKey)
+	assert.True(t, ok)
+	assert.Equal(t, "session-cookie", session)
+
+	// sessions can not be read with different credentials
+	other := NewSessionStore(store, logrus.StandardLogger(), "https://vcenter/sdk", "user", "other")
+	_, ok = other.load(soapSessionKey)
+	assert.False(t, ok)
+
+	// every save uses a new salt
+	s.save(restSessionKey, "session-cookie")
+	var again string
+	_, err = store.Get(restSessionKey, &again)
+	require.NoError(t, err)
+	assert.NotEqual(t, stored, again)
+}
+
+func TestSessionStore_IgnoresUnsaltedSessions(t *testing.T) {
+	store := persist.NewInMemoryStore()
+	s := NewSessionStore(store, logrus.StandardLogger(), "https://vcenter/sdk", "user", "pass")
+
+	// sessions stored by previous versions with an unsalted key make the integration log in again
+	key := sha256.Sum256([]byte("https://vcenter/sdk\x00user\x00pass"))
+	block, err := aes.NewCipher(key[:])
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	store.Set(soapSessionKey, base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte("session-cookie"), nil)))
+
+	_, ok := s.load(soapSessionKey)
+	assert.False(t, ok)
+}
+
+func TestNewWithSessionStore_ReusesActiveSessions(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		u := vc.URL().String()
+		s := NewSessionStore(persist.NewInMemoryStore(), logrus.StandardLogger(), u, "user", "pass")
+
+		first, err := NewWithSessionStore(u, "user", "pass", false, s)
+		require.NoError(t, err)
+		firstSession, err := first.SessionManager.UserSession(ctx)
+		require.NoError(t, err)
+		firstRest, err := NewRestWithSessionStore(first, "user", "pass", s)
+		require.NoError(t, err)
+
+		// the session stored is reused
+		second, err := NewWithSessionStore(u, "user", "pass", false, s)
+		require.NoError(t, err)
+		secondSession, err := second.SessionManager.UserSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, firstSession.Key, secondSession.Key)
+		secondRest, err := NewRestWithSessionStore(second, "user", "pass", s)
+		require.NoError(t, err)
+		assert.Equal(t, firstRest.SessionID(), secondRest.SessionID())
+
+		// expired sessions are replaced by new ones
+		require.NoError(t, Logout(second))
+		require.NoError(t, LogoutRest(secondRest))
+
+		third, err := NewWithSessionStore(u, "user", "pass", false, s)
+		require.NoError(t, err)
+		thirdSession, err := third.SessionManager.UserSession(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, firstSession.Key, thirdSession.Key)
+		thirdRest, err := NewRestWithSessionStore(third, "user", "pass", s)
+		require.NoError(t, err)
+		assert.NotEqual(t, firstRest.SessionID(), thirdRest.SessionID())
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Alarms collects the alarms currently triggered on every datacenter
+func Alarms(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		collectTriggeredAlarms(config, dc)
+	}
+}
+
+// collectTriggeredAlarms fetches the alarms currently triggered on the datacenter and on its descendants, which are
+// all listed by the triggeredAlarmState of the datacenter, along with the name of each alarm
+func collectTriggeredAlarms(config *config.Config, dc *model.Datacenter) {
+	ctx := context.Background()
+	logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+	alarms := make(map[types.ManagedObjectReference][]model.TriggeredAlarm)
+	defer func() {
+		dc.TriggeredAlarms = alarms
+	}()
+
+	pc := property.DefaultCollector(config.VMWareClient.Client)
+
+	var datacenter mo.Datacenter
+	err := pc.RetrieveOne(ctx, dc.Datacenter.Self, []string{"triggeredAlarmState"}, &datacenter)
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve triggered alarms")
+		return
+	}
+	if len(datacenter.TriggeredAlarmState) == 0 {
+		return
+	}
+
+	var refs []types.ManagedObjectReference
+	seen := make(map[types.ManagedObjectReference]bool)
+	for _, state := range datacenter.TriggeredAlarmState {
+		if !seen[state.Alarm] {
+			seen[state.Alarm] = true
+			refs = append(refs, state.Alarm)
+		}
+	}
+
+	names := make(map[types.ManagedObjectReference]string)
+	var definitions []mo.Alarm
+	err = pc.Retrieve(ctx, refs, []string{"info.name"}, &definitions)
+	if err != nil {
+		// alarms are reported anyway, without their name
+		logger.WithError(err).Warn("failed to retrieve alarm definitions")
+	}
+	for _, definition := range definitions {
+		names[definition.Self] = definition.Info.Name
+	}
+
+	for _, state := range datacenter.TriggeredAlarmState {
+		alarms[state.Entity] = append(alarms[state.Entity], model.TriggeredAlarm{
+			AlarmState: state,
+			Name:       names[state.Alarm],
+		})
+	}
+}
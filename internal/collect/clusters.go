@@ -13,12 +13,15 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 )
 
+// Reference: https://code.vmware.com/apis/704/vsphere/vim.ClusterComputeResource.html
+var clusterProperties = []string{"summary", "host", "datastore", "name", "network", "configuration"}
+
 // Clusters VMWare
 func Clusters(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	propertiesToRetrieve := []string{"summary", "host", "datastore", "name", "network", "configuration"}
+	propertiesToRetrieve := clusterProperties
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -35,7 +38,6 @@ func Clusters(config *config.Config) {
 		}()
 
 		var clusters []mo.ClusterComputeResource
-		// Reference: https://code.vmware.com/apis/704/vsphere/vim.ClusterComputeResource.html
 		err = cv.Retrieve(ctx, []string{CLUSTER}, propertiesToRetrieve, &clusters)
 		if err != nil {
 			logger.WithError(err).Error("failed to retrieve ClusterComputeResource")
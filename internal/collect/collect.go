@@ -2,8 +2,11 @@ package collect
 
 import (
 	"errors"
-	"github.com/newrelic/nri-vsphere/internal/config"
 	"sync"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 const (
@@ -14,6 +17,10 @@ const (
 	RESOURCE_POOL   = "ResourcePool"
 	NETWORK         = "Network"
 	CLUSTER         = "ClusterComputeResource"
+	STORAGE_POD     = "StoragePod"
+
+	DISTRIBUTED_SWITCH    = "DistributedVirtualSwitch"
+	DISTRIBUTED_PORTGROUP = "DistributedVirtualPortgroup"
 )
 
 func CollectData(config *config.Config) error {
@@ -38,7 +45,7 @@ func CollectData(config *config.Config) error {
 
 	// fetch vmware data async
 	var wg sync.WaitGroup
-	wg.Add(6)
+	wg.Add(9)
 	go func() {
 		defer wg.Done()
 		VirtualMachines(config)
@@ -73,7 +80,48 @@ func CollectData(config *config.Config) error {
 		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting resourcepools data")
 
 	}()
+	go func() {
+		defer wg.Done()
+		StoragePods(config)
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting storage pods data")
+	}()
+	go func() {
+		defer wg.Done()
+		DistributedSwitches(config)
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting distributed switches data")
+	}()
+	go func() {
+		defer wg.Done()
+		if config.AlarmCollectionEnabled() {
+			Alarms(config)
+			config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting triggered alarms")
+		}
+	}()
 	wg.Wait()
 
+	// vSAN data is collected for the clusters already retrieved
+	if config.VsanCollectionEnabled() {
+		Vsan(config)
+		config.Logrus.WithField("seconds", config.Uptime()).Debug("after collecting vsan data")
+	}
+
 	return nil
 }
+
+// collectPerfMetrics collects the perf metrics of the given objects adding them to the datacenter.
+// Objects not matching the tag filter are skipped.
+func collectPerfMetrics(config *config.Config, dc *model.Datacenter, refs []types.ManagedObjectReference, metrics []types.PerfMetricId, intervalID int32) {
+	var refsToCollect []types.ManagedObjectReference
+	for _, ref := range refs {
+		if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(ref) {
+			continue
+		}
+		refsToCollect = append(refsToCollect, ref)
+	}
+	if len(refsToCollect) == 0 {
+		return
+	}
+
+	collectedData := config.PerfCollector.Collect(refsToCollect, metrics, intervalID)
+	dc.AddPerfMetrics(collectedData)
+}
@@ -5,7 +5,10 @@ package collect
 
 import (
 	"context"
+	"math"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/newrelic/infra-integrations-sdk/v3/persist"
 	"github.com/newrelic/nri-vsphere/internal/cache"
@@ -17,12 +20,42 @@ import (
 
 // Datacenters VMWare
 func Datacenters(config *config.Config) error {
+	datacenters, err := retrieveDatacenters(config)
+	if err != nil {
+		return err
+	}
+
+	// cache store for events
+	cs, err := newCacheStore(config)
+	if err != nil {
+		config.Logrus.WithError(err).Warn("could not create cache for vsphere events. all events will be returned")
+	}
+
+	for _, dc := range datacenters {
+		if config.EventCollectionEnabled() {
+			c := cache.NewCache(dc.Datacenter.Name, cs)
+			collectEvents(config, *dc.Datacenter, dc, c)
+		}
+		if config.TaskCollectionEnabled() {
+			c := cache.NewCache(taskCacheName(dc.Datacenter.Name), cs)
+			collectTasks(config, *dc.Datacenter, dc, c)
+		}
+
+		config.Datacenters = append(config.Datacenters, dc)
+	}
+
+	return nil
+}
+
+// retrieveDatacenters returns the datacenters of the endpoint not excluded by the tag filter
+func retrieveDatacenters(config *config.Config) ([]*model.Datacenter, error) {
 	ctx := context.Background()
 	m := config.ViewManager
 
 	cv, err := m.CreateContainerView(ctx, config.VMWareClient.ServiceContent.RootFolder, []string{DATACENTER}, true)
 	if err != nil {
-		config.Logrus.WithError(err).Fatal("failed to create Datacenter container view")
+		config.Logrus.WithError(err).Error("failed to create Datacenter container view")
+		return nil, err
 	}
 
 	defer func() {
@@ -36,7 +69,7 @@ func Datacenters(config *config.Config) error {
 	err = cv.Retrieve(ctx, []string{DATACENTER}, []string{"name", "overallStatus"}, &datacenters)
 	if err != nil {
 		config.Logrus.WithError(err).Error("failed to retrieve Datacenters")
-		return err
+		return nil, err
 	}
 
 	if config.TagCollectionEnabled() {
@@ -46,12 +79,7 @@ func Datacenters(config *config.Config) error {
 		}
 	}
 
-	// cache store for events
-	cs, err := newCacheStore(config)
-	if err != nil {
-		config.Logrus.WithError(err).Warn("could not create cache for vsphere events. all events will be returned")
-	}
-
+	var result []*model.Datacenter
 	for i, d := range datacenters {
 		// for datacenters we keep the filtering here since there it is the root of the resource tree
 		if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(d.Reference()) {
@@ -60,22 +88,25 @@ func Datacenters(config *config.Config) error {
 			continue
 		}
 
-		newDatacenter := model.NewDatacenter(&datacenters[i])
-
-		if config.EventCollectionEnabled() {
-			c := cache.NewCache(d.Name, cs)
-			collectEvents(config, d, newDatacenter, c)
-		}
-
-		config.Datacenters = append(config.Datacenters, newDatacenter)
+		result = append(result, model.NewDatacenter(&datacenters[i]))
 	}
 
-	return nil
+	return result, nil
 }
 
 func collectEvents(config *config.Config, d mo.Datacenter, newDatacenter *model.Datacenter, c *cache.Cache) {
 	//https://pubs.vmware.com/vsphere-51/index.jsp?topic=%2Fcom.vmware.wssdk.apiref.doc%2Fvim.HistoryCollector.html
-	ed, err := events.NewEventDispacher(config.VMWareClient.Client, d.Self, config.Logrus, c)
+	options := events.Options{
+		Filter: events.NewEventFilter(
+			config.Args.EventsIncludeTypes, config.Args.EventsExcludeTypes,
+			config.Args.EventsIncludeCategories, config.Args.EventsExcludeCategories,
+			config.Args.EventsIncludeUsers, config.Args.EventsExcludeUsers,
+		),
+		MaxBackfill: eventsMaxBackfill(config),
+		CatchUp:     config.Args.EnableEventsCatchUp,
+		MaxEvents:   config.Args.EventsCatchUpMaxEvents,
+	}
+	ed, err := events.NewEventDispacher(config.VMWareClient.Client, d.Self, config.Logrus, c, options)
 	if err != nil {
 		config.Logrus.WithError(err).Error("error while creating event Dispatcher")
 		return
@@ -86,12 +117,69 @@ func collectEvents(config *config.Config, d mo.Datacenter, newDatacenter *model.
 	ed.CollectEvents(config.Args.EventsPageSize)
 }
 
+func collectTasks(config *config.Config, d mo.Datacenter, newDatacenter *model.Datacenter, c *cache.Cache) {
+	options := events.Options{
+		MaxBackfill: eventsMaxBackfill(config),
+		CatchUp:     config.Args.EnableEventsCatchUp,
+		MaxEvents:   config.Args.EventsCatchUpMaxEvents,
+	}
+	td, err := events.NewTaskDispacher(config.VMWareClient.Client, d.Self, config.Logrus, c, options)
+	if err != nil {
+		config.Logrus.WithError(err).Error("error while creating task Dispatcher")
+		return
+	}
+	defer td.Cancel()
+
+	newDatacenter.TaskDispacher = td
+	td.CollectTasks(config.Args.TasksPageSize)
+}
+
+// eventsMaxBackfill returns the max backfill window of events and tasks, or 0 to use the default one if not valid
+func eventsMaxBackfill(config *config.Config) time.Duration {
+	maxBackfill, err := time.ParseDuration(config.Args.EventsMaxBackfill)
+	if err != nil || maxBackfill <= 0 {
+		config.Logrus.WithField("eventsMaxBackfill", config.Args.EventsMaxBackfill).Warn("invalid events max backfill, using default value")
+		return 0
+	}
+	return maxBackfill
+}
+
+// taskCacheName returns the name under which the timestamp of the last task collected in the datacenter is kept,
+// distinct from the one of its events
+func taskCacheName(datacenterName string) string {
+	return datacenterName + "_tasks"
+}
+
+// checkpointStoreTTL is the time to live of the events and tasks checkpoints. They never expire, since the SDK
+// skips loading a store file older than its TTL and every event of an outage longer than that would be dropped.
+// The events_max_backfill argument limits instead how far back a stale checkpoint is read from.
+const checkpointStoreTTL = time.Duration(math.MaxInt64)
+
 func newCacheStore(config *config.Config) (persist.Storer, error) {
 	// we have to set a distinct default path otherwise it gets overwritten by the default Infra SDK store
-	path := persist.DefaultPath(config.IntegrationName + "_timestamps")
-	store, err := persist.NewFileStore(path, config.Logrus, time.Hour*24)
+	return newCheckpointStore(persist.DefaultPath(cacheStoreName(config)), config)
+}
+
+func newCheckpointStore(path string, config *config.Config) (persist.Storer, error) {
+	store, err := persist.NewFileStore(path, config.Logrus, checkpointStoreTTL)
 	if err != nil {
 		store = persist.NewInMemoryStore()
 	}
 	return store, err
 }
+
+// cacheStoreName returns the name of the events cache file. When collecting multiple endpoints each one gets its
+// own file, since they are collected concurrently and datacenter names could be repeated across endpoints.
+func cacheStoreName(config *config.Config) string {
+	name := config.IntegrationName + "_timestamps"
+	if config.EndpointID == "" {
+		return name
+	}
+	endpoint := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' {
+			return r
+		}
+		return '-'
+	}, config.EndpointID)
+	return name + "_" + endpoint
+}
@@ -2,9 +2,15 @@ package collect
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/newrelic/nri-vsphere/internal/cache"
 	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/vmware/govmomi/vim25/mo"
-	"testing"
 
 	"github.com/newrelic/nri-vsphere/internal/client"
 	"github.com/newrelic/nri-vsphere/internal/config"
@@ -12,6 +18,7 @@ import (
 
 	logrus "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/vapi/rest"
@@ -123,6 +130,44 @@ func Test_ListDatacenters_WithNonEmptyFilter(t *testing.T) {
 	})
 }
 
+func Test_CheckpointStore_SurvivesLongOutages(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger()}
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+
+	// given a checkpoint saved before an outage
+	store, err := newCheckpointStore(path, cfg)
+	require.NoError(t, err)
+	lastTimestamp := time.Now().Add(-72 * time.Hour)
+	c := cache.NewCache("DC0", store)
+	require.NoError(t, c.WriteTimestampCache(lastTimestamp))
+	require.NoError(t, c.WriteKeyCache(4242))
+
+	// when the store file is read again after three days
+	aged := time.Now().Add(-72 * time.Hour)
+	require.NoError(t, os.Chtimes(path, aged, aged))
+	persist.SetNow(func() time.Time { return time.Now().Add(72 * time.Hour) })
+	defer persist.SetNow(time.Now)
+
+	store, err = newCheckpointStore(path, cfg)
+	require.NoError(t, err)
+	c = cache.NewCache("DC0", store)
+
+	// then the checkpoint is still there
+	timestamp, err := c.ReadTimestampCache()
+	require.NoError(t, err)
+	assert.Equal(t, lastTimestamp.UnixNano(), timestamp.UnixNano())
+	key, err := c.ReadKeyCache()
+	require.NoError(t, err)
+	assert.Equal(t, int32(4242), key)
+
+	// and it is not dropped when saved again
+	require.NoError(t, store.Save())
+	store, err = newCheckpointStore(path, cfg)
+	require.NoError(t, err)
+	_, err = cache.NewCache("DC0", store).ReadKeyCache()
+	assert.NoError(t, err)
+}
+
 func addTag(ctx context.Context, m *tags.Manager, vc *vim25.Client, category string, value string) {
 	categoryID, _ := m.CreateCategory(ctx, &tags.Category{
 		AssociableTypes: []string{DATACENTER},
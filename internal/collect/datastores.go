@@ -12,13 +12,15 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.Datastore.html
+var datastoreProperties = []string{"name", "summary", "overallStatus", "vm", "host", "info"}
+
 // Datastores collects data of all datastores
 func Datastores(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.Datastore.html
-	propertiesToRetrieve := []string{"name", "summary", "overallStatus", "vm", "host", "info"}
+	propertiesToRetrieve := datastoreProperties
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -0,0 +1,132 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.DistributedVirtualSwitch.html
+var distributedSwitchProperties = []string{"name", "uuid", "summary", "config", "portgroup", "runtime", "overallStatus"}
+
+// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.dvs.DistributedVirtualPortgroup.html
+var distributedPortgroupProperties = []string{"name", "config", "host", "vm", "overallStatus"}
+
+// DistributedSwitches collects data of all distributed switches and distributed portgroups
+func DistributedSwitches(config *config.Config) {
+	ctx := context.Background()
+	m := config.ViewManager
+
+	for i, dc := range config.Datacenters {
+		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+		cv, err := m.CreateContainerView(ctx, dc.Datacenter.Reference(), []string{DISTRIBUTED_SWITCH, DISTRIBUTED_PORTGROUP}, true)
+		if err != nil {
+			logger.WithError(err).Error("failed to create DistributedVirtualSwitch container view")
+			continue
+		}
+		defer func() {
+			err := cv.Destroy(ctx)
+			if err != nil {
+				logger.WithError(err).Error("error while cleaning up distributed switch container view")
+			}
+		}()
+
+		var switches []mo.DistributedVirtualSwitch
+		err = cv.Retrieve(ctx, []string{DISTRIBUTED_SWITCH}, distributedSwitchProperties, &switches)
+		if err != nil {
+			logger.WithError(err).Error("failed to retrieve DistributedVirtualSwitches")
+			continue
+		}
+
+		var portgroups []mo.DistributedVirtualPortgroup
+		err = cv.Retrieve(ctx, []string{DISTRIBUTED_PORTGROUP}, distributedPortgroupProperties, &portgroups)
+		if err != nil {
+			logger.WithError(err).Error("failed to retrieve DistributedVirtualPortgroups")
+			continue
+		}
+
+		var refs []types.ManagedObjectReference
+		for j, sw := range switches {
+			config.Datacenters[i].DistributedSwitches[sw.Self] = &switches[j]
+			refs = append(refs, sw.Self)
+		}
+		for j, pg := range portgroups {
+			config.Datacenters[i].DistributedPortgroups[pg.Self] = &portgroups[j]
+			refs = append(refs, pg.Self)
+		}
+
+		if config.TagCollectionEnabled() {
+			_, err = config.TagCollector.FetchTagsForObjects(refs)
+			if err != nil {
+				logger.WithError(err).Warn("failed to retrieve tags for distributed switches")
+			} else {
+				logger.WithField("seconds", config.Uptime()).Debug("distributed switches tags collected")
+			}
+		}
+
+		collectDistributedPortCounts(config, dc)
+	}
+}
+
+// collectDistributedPortCounts counts the total, used and blocked ports of each distributed switch and portgroup
+// of the datacenter. Ports are not part of the inventory, so they are fetched from each switch every time.
+func collectDistributedPortCounts(config *config.Config, dc *model.Datacenter) {
+	ctx := context.Background()
+	logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+	portgroupsByKey := make(map[string]types.ManagedObjectReference)
+	for _, pg := range dc.DistributedPortgroups {
+		portgroupsByKey[pg.Config.Key] = pg.Self
+	}
+
+	counts := make(map[types.ManagedObjectReference]*model.PortCounts)
+	for _, sw := range dc.DistributedSwitches {
+		ports, err := object.NewDistributedVirtualSwitch(config.VMWareClient.Client, sw.Self).FetchDVPorts(ctx, nil)
+		if err != nil {
+			logger.WithError(err).WithField("distributedSwitch", sw.Name).Warn("failed to retrieve distributed switch ports")
+			continue
+		}
+
+		switchCounts := &model.PortCounts{}
+		counts[sw.Self] = switchCounts
+		for _, port := range ports {
+			portCounts := []*model.PortCounts{switchCounts}
+			if pg, ok := portgroupsByKey[port.PortgroupKey]; ok {
+				if _, ok := counts[pg]; !ok {
+					counts[pg] = &model.PortCounts{}
+				}
+				portCounts = append(portCounts, counts[pg])
+			}
+
+			for _, c := range portCounts {
+				c.Total++
+				if port.Connectee != nil {
+					c.Used++
+				}
+				if isPortBlocked(port) {
+					c.Blocked++
+				}
+			}
+		}
+	}
+	dc.DistributedPortCounts = counts
+}
+
+func isPortBlocked(port types.DistributedVirtualPort) bool {
+	if port.State != nil && port.State.RuntimeInfo != nil {
+		return port.State.RuntimeInfo.Blocked
+	}
+	if port.Config.Setting != nil {
+		blocked := port.Config.Setting.GetDVPortSetting().Blocked
+		return blocked != nil && blocked.Value != nil && *blocked.Value
+	}
+	return false
+}
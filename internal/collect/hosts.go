@@ -11,14 +11,16 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.HostSystem.html
+var hostProperties = []string{"summary", "overallStatus", "config", "network", "vm", "runtime", "parent", "datastore", "hardware.systemInfo", "hardware.biosInfo"}
+
 // Hosts VMWare
 func Hosts(config *config.Config) {
 
 	ctx := context.Background()
 	m := config.ViewManager
 
-	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.HostSystem.html
-	propertiesToRetrieve := []string{"summary", "overallStatus", "config", "network", "vm", "runtime", "parent", "datastore"}
+	propertiesToRetrieve := hostProperties
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
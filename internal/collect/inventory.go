@@ -0,0 +1,528 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/persist"
+	"github.com/newrelic/nri-vsphere/internal/cache"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// inventoryTypes are the managed object types kept up to date by the Inventory
+// distributed portgroups are included as networks
+var inventoryTypes = []string{VIRTUAL_MACHINE, HOST, DATASTORE, NETWORK, CLUSTER, RESOURCE_POOL, STORAGE_POD, DISTRIBUTED_SWITCH}
+
+// Inventory keeps the objects of the datacenters of an endpoint up to date across collections.
+// A PropertyFilter is registered once per datacenter in a session-specific PropertyCollector and each Sync
+// only fetches, through WaitForUpdatesEx, the objects that changed since the previous one. Changes are applied
+// to the model.Datacenter maps in config.Datacenters, which therefore always hold a live snapshot of the inventory.
+// One more filter watches the datacenters of the endpoint, so the whole inventory is fetched again when one is
+// added, removed or renamed.
+// Tags, events and performance metrics are collected separately since they are not part of the inventory.
+type Inventory struct {
+	config      *config.Config
+	datacenters []*model.Datacenter
+	collector   *property.Collector
+	views       []*view.ContainerView
+	filters     map[types.ManagedObjectReference]*model.Datacenter
+	objects     map[types.ManagedObjectReference]mo.Reference
+	version     string
+	added       []types.ManagedObjectReference
+	// reconfigured holds the vms added or whose configuration changed in the last sync
+	reconfigured map[types.ManagedObjectReference]bool
+	cacheStore   persist.Storer
+
+	// datacentersFilter watches the datacenters of the endpoint
+	datacentersFilter types.ManagedObjectReference
+	// datacentersChanged is set when the datacenters of the endpoint changed, and stale when an update of an
+	// object not in the inventory was received. Either way the whole inventory must be fetched again
+	datacentersChanged bool
+	stale              bool
+}
+
+// NewInventory retrieves the datacenters of the endpoint and registers a PropertyFilter for each one of them.
+// Objects are not fetched until the first Sync.
+func NewInventory(config *config.Config) (*Inventory, error) {
+	if config.TagCollectionEnabled() {
+		err := config.TagCollector.BuildTagCache()
+		if err != nil {
+			config.Logrus.WithError(err).Error("failed to build tag cache")
+		}
+	}
+
+	datacenters, err := retrieveDatacenters(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(datacenters) == 0 {
+		return nil, fmt.Errorf("no datacenter was collected. this is most likely an error in your filter")
+	}
+
+	inv := &Inventory{
+		config:      config,
+		datacenters: datacenters,
+	}
+	err = inv.createCollector()
+	if err != nil {
+		return nil, err
+	}
+	config.Datacenters = datacenters
+
+	inv.cacheStore, err = newCacheStore(config)
+	if err != nil {
+		config.Logrus.WithError(err).Warn("could not create cache for vsphere events. all events will be returned")
+	}
+
+	return inv, nil
+}
+
+// createCollector creates the PropertyCollector and its filters. The first update received from a newly created
+// collector includes every object of the datacenters.
+func (inv *Inventory) createCollector() error {
+	ctx := context.Background()
+
+	pc, err := property.DefaultCollector(inv.config.VMWareClient.Client).Create(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create property collector: %v", err)
+	}
+	inv.collector = pc
+	inv.filters = map[types.ManagedObjectReference]*model.Datacenter{}
+	inv.objects = map[types.ManagedObjectReference]mo.Reference{}
+	inv.version = ""
+	inv.datacentersChanged = false
+	inv.stale = false
+
+	err = inv.createDatacentersFilter(ctx)
+	if err != nil {
+		inv.Destroy()
+		return err
+	}
+
+	for _, dc := range inv.datacenters {
+		err = inv.createFilter(ctx, dc)
+		if err != nil {
+			inv.Destroy()
+			return err
+		}
+		dc.Hosts = make(map[types.ManagedObjectReference]*mo.HostSystem)
+		dc.Clusters = make(map[types.ManagedObjectReference]*mo.ClusterComputeResource)
+		dc.ResourcePools = make(map[types.ManagedObjectReference]*mo.ResourcePool)
+		dc.Datastores = make(map[types.ManagedObjectReference]*mo.Datastore)
+		dc.Networks = make(map[types.ManagedObjectReference]*mo.Network)
+		dc.VirtualMachines = make(map[types.ManagedObjectReference]*mo.VirtualMachine)
+		dc.StoragePods = make(map[types.ManagedObjectReference]*mo.StoragePod)
+		dc.DistributedSwitches = make(map[types.ManagedObjectReference]*mo.DistributedVirtualSwitch)
+		dc.DistributedPortgroups = make(map[types.ManagedObjectReference]*mo.DistributedVirtualPortgroup)
+	}
+	return nil
+}
+
+// createDatacentersFilter registers a filter on the names of the datacenters of the endpoint
+func (inv *Inventory) createDatacentersFilter(ctx context.Context) error {
+	cv, err := inv.config.ViewManager.CreateContainerView(ctx, inv.config.VMWareClient.ServiceContent.RootFolder, []string{DATACENTER}, true)
+	if err != nil {
+		return fmt.Errorf("failed to create datacenters container view: %v", err)
+	}
+	inv.views = append(inv.views, cv)
+
+	filter, err := inv.collector.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{
+				{
+					Obj:  cv.Reference(),
+					Skip: types.NewBool(true),
+					SelectSet: []types.BaseSelectionSpec{
+						&types.TraversalSpec{
+							Type: "ContainerView",
+							Path: "view",
+						},
+					},
+				},
+			},
+			PropSet: []types.PropertySpec{
+				{Type: DATACENTER, PathSet: []string{"name"}},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create datacenters property filter: %v", err)
+	}
+	inv.datacentersFilter = filter.Reference()
+	return nil
+}
+
+func (inv *Inventory) createFilter(ctx context.Context, dc *model.Datacenter) error {
+	cv, err := inv.config.ViewManager.CreateContainerView(ctx, dc.Datacenter.Reference(), inventoryTypes, true)
+	if err != nil {
+		return fmt.Errorf("failed to create inventory container view for datacenter %s: %v", dc.Datacenter.Name, err)
+	}
+	inv.views = append(inv.views, cv)
+
+	filter, err := inv.collector.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{
+				{
+					Obj:  cv.Reference(),
+					Skip: types.NewBool(true),
+					SelectSet: []types.BaseSelectionSpec{
+						&types.TraversalSpec{
+							Type: "ContainerView",
+							Path: "view",
+						},
+					},
+				},
+			},
+			PropSet: []types.PropertySpec{
+				{Type: VIRTUAL_MACHINE, PathSet: vmProperties(inv.config)},
+				{Type: HOST, PathSet: hostProperties},
+				{Type: DATASTORE, PathSet: datastoreProperties},
+				{Type: NETWORK, PathSet: networkProperties},
+				{Type: CLUSTER, PathSet: clusterProperties},
+				{Type: RESOURCE_POOL, PathSet: resourcePoolProperties},
+				{Type: STORAGE_POD, PathSet: storagePodProperties},
+				{Type: DISTRIBUTED_SWITCH, PathSet: distributedSwitchProperties},
+				{Type: DISTRIBUTED_PORTGROUP, PathSet: distributedPortgroupProperties},
+			},
+		},
+		// changes are always reported with the whole value of the properties requested
+		PartialUpdates: false,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create inventory property filter for datacenter %s: %v", dc.Datacenter.Name, err)
+	}
+	inv.filters[filter.Reference()] = dc
+	return nil
+}
+
+// Destroy removes the PropertyCollector along with its filters and the container views used by the Inventory
+func (inv *Inventory) Destroy() {
+	ctx := context.Background()
+
+	if inv.collector != nil {
+		err := inv.collector.Destroy(ctx)
+		if err != nil {
+			inv.config.Logrus.WithError(err).Error("error while cleaning up inventory property collector")
+		}
+		inv.collector = nil
+	}
+	for _, cv := range inv.views {
+		err := cv.Destroy(ctx)
+		if err != nil {
+			inv.config.Logrus.WithError(err).Error("error while cleaning up inventory container view")
+		}
+	}
+	inv.views = nil
+}
+
+// Sync applies the inventory changes occurred since the previous call. When full is set, or after an error,
+// the whole inventory is fetched again instead, as it is when the datacenters of the endpoint changed. Tags of the objects added, the ports of the distributed
+// switches, the storage policies of the virtual disks, the triggered alarms and the vSAN data of the clusters
+// are fetched as well when enabled.
+func (inv *Inventory) Sync(full bool) error {
+	config := inv.config
+
+	if full {
+		inv.Destroy()
+	}
+	inv.added = nil
+	inv.reconfigured = make(map[types.ManagedObjectReference]bool)
+
+	err := inv.sync()
+	if err != nil {
+		return err
+	}
+	if inv.datacentersChanged || inv.stale {
+		err = inv.resync()
+		if err != nil {
+			return err
+		}
+	}
+	config.Logrus.WithField("seconds", config.Uptime()).Debug("after syncing inventory changes")
+
+	for _, dc := range inv.datacenters {
+		collectDistributedPortCounts(config, dc)
+		collectVirtualDiskStoragePolicies(config, dc, inv.reconfigured)
+		if config.AlarmCollectionEnabled() {
+			collectTriggeredAlarms(config, dc)
+		}
+	}
+	Vsan(config)
+
+	if config.TagCollectionEnabled() && len(inv.added) > 0 {
+		_, err := config.TagCollector.FetchTagsForObjects(inv.added)
+		if err != nil {
+			config.Logrus.WithError(err).Warn("failed to retrieve tags of the objects added")
+		}
+	}
+	return nil
+}
+
+// CollectTags refreshes the tags cache and fetches again the tags of every object of the inventory
+func (inv *Inventory) CollectTags() {
+	config := inv.config
+
+	err := config.TagCollector.BuildTagCache()
+	if err != nil {
+		config.Logrus.WithError(err).Error("failed to build tag cache")
+		return
+	}
+	for _, dc := range config.Datacenters {
+		inv.collectTags(dc)
+	}
+}
+
+// CollectEvents collects the events occurred in each datacenter since the last ones collected, along with the tasks
+// completed since then and the ones in progress when tasks collection is enabled
+func (inv *Inventory) CollectEvents() {
+	for _, dc := range inv.config.Datacenters {
+		if inv.config.EventCollectionEnabled() {
+			c := cache.NewCache(dc.Datacenter.Name, inv.cacheStore)
+			collectEvents(inv.config, *dc.Datacenter, dc, c)
+		}
+		if inv.config.TaskCollectionEnabled() {
+			c := cache.NewCache(taskCacheName(dc.Datacenter.Name), inv.cacheStore)
+			collectTasks(inv.config, *dc.Datacenter, dc, c)
+		}
+	}
+}
+
+// ClearEvents removes the events and tasks collected so they are not reported again
+func (inv *Inventory) ClearEvents() {
+	for _, dc := range inv.config.Datacenters {
+		dc.EventDispacher = nil
+		dc.TaskDispacher = nil
+	}
+}
+
+// ClearPerfMetrics removes the performance metrics collected so they are not reported again with a later timestamp
+func (inv *Inventory) ClearPerfMetrics() {
+	for _, dc := range inv.config.Datacenters {
+		dc.ClearPerfMetrics()
+	}
+}
+
+// CollectPerfMetrics replaces the performance metrics of the objects of the inventory with the latest values
+func (inv *Inventory) CollectPerfMetrics() {
+	for _, dc := range inv.config.Datacenters {
+		dc.ClearPerfMetrics()
+		inv.collectPerfMetrics(dc)
+	}
+}
+
+// resync fetches the whole inventory again, along with the datacenters of the endpoint if they changed
+func (inv *Inventory) resync() error {
+	config := inv.config
+
+	if inv.datacentersChanged {
+		config.Logrus.Info("datacenters changed, fetching the whole inventory again")
+		datacenters, err := retrieveDatacenters(config)
+		if err != nil {
+			return err
+		}
+		if len(datacenters) == 0 {
+			return fmt.Errorf("no datacenter was collected. this is most likely an error in your filter")
+		}
+		inv.datacenters = datacenters
+		config.Datacenters = datacenters
+	} else {
+		config.Logrus.Warn("received an update of an object not in the inventory, fetching the whole inventory again")
+	}
+
+	inv.Destroy()
+	inv.added = nil
+	inv.reconfigured = make(map[types.ManagedObjectReference]bool)
+	return inv.sync()
+}
+
+// sync fetches the pending updates from the PropertyCollector. The collector is created again if it was destroyed,
+// so every object of the datacenters is received as a newly entered one.
+func (inv *Inventory) sync() error {
+	ctx := context.Background()
+
+	if inv.collector == nil {
+		err := inv.createCollector()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the first updates of a collector hold every object, including the datacenters already known
+	initial := inv.version == ""
+	for {
+		res, err := methods.WaitForUpdatesEx(ctx, inv.config.VMWareClient.Client, &types.WaitForUpdatesEx{
+			This:    inv.collector.Reference(),
+			Version: inv.version,
+			Options: &types.WaitOptions{
+				// do not block waiting for changes, return the ones already available if any
+				MaxWaitSeconds: types.NewInt32(0),
+			},
+		})
+		if err != nil {
+			// the whole inventory is fetched again on the next call
+			inv.Destroy()
+			return fmt.Errorf("failed to wait for inventory updates: %v", err)
+		}
+
+		set := res.Returnval
+		// no changes since the last version
+		if set == nil {
+			return nil
+		}
+		inv.version = set.Version
+
+		for _, fs := range set.FilterSet {
+			if fs.Filter == inv.datacentersFilter {
+				inv.datacentersChanged = inv.datacentersChanged || (!initial && len(fs.ObjectSet) > 0)
+				continue
+			}
+			dc, ok := inv.filters[fs.Filter]
+			if !ok {
+				continue
+			}
+			for _, update := range fs.ObjectSet {
+				inv.applyUpdate(dc, update)
+			}
+		}
+
+		// a truncated set means there are more updates to be fetched
+		if set.Truncated == nil || !*set.Truncated {
+			return nil
+		}
+	}
+}
+
+func (inv *Inventory) applyUpdate(dc *model.Datacenter, update types.ObjectUpdate) {
+	ref := update.Obj
+
+	switch update.Kind {
+	case types.ObjectUpdateKindLeave:
+		delete(inv.objects, ref)
+		removeInventoryObject(dc, ref)
+	case types.ObjectUpdateKindModify:
+		// changes are applied in place, so the datacenter maps already point to the updated object
+		if obj, ok := inv.objects[ref]; ok {
+			changeVersion := vmChangeVersion(obj)
+			mo.ApplyPropertyChange(obj, update.ChangeSet)
+			if ref.Type == VIRTUAL_MACHINE && vmChangeVersion(obj) != changeVersion {
+				inv.reconfigured[ref] = true
+			}
+			return
+		}
+		// the changes hold only the properties modified, so the object would be left incomplete
+		inv.config.Logrus.WithField("object", ref.String()).Debug("received an update of an object not in the inventory")
+		inv.stale = true
+	case types.ObjectUpdateKindEnter:
+		content := types.ObjectContent{Obj: ref}
+		for _, change := range update.ChangeSet {
+			content.PropSet = append(content.PropSet, types.DynamicProperty{Name: change.Name, Val: change.Val})
+		}
+		content.MissingSet = update.MissingSet
+
+		v, err := mo.ObjectContentToType(content, true)
+		if err != nil {
+			inv.config.Logrus.WithError(err).WithField("object", ref.String()).Warn("failed to load inventory object")
+			return
+		}
+		obj, ok := v.(mo.Reference)
+		if !ok {
+			return
+		}
+		inv.objects[ref] = obj
+		inv.added = append(inv.added, ref)
+		if ref.Type == VIRTUAL_MACHINE {
+			inv.reconfigured[ref] = true
+		}
+		addInventoryObject(dc, obj)
+	}
+}
+
+// vmChangeVersion returns the version of the vm configuration, which changes every time the vm is reconfigured
+func vmChangeVersion(obj mo.Reference) string {
+	vm, ok := obj.(*mo.VirtualMachine)
+	if !ok || vm.Config == nil {
+		return ""
+	}
+	return vm.Config.ChangeVersion
+}
+
+func addInventoryObject(dc *model.Datacenter, obj mo.Reference) {
+	switch o := obj.(type) {
+	case *mo.VirtualMachine:
+		dc.VirtualMachines[o.Self] = o
+	case *mo.HostSystem:
+		dc.Hosts[o.Self] = o
+	case *mo.Datastore:
+		dc.Datastores[o.Self] = o
+	case *mo.ClusterComputeResource:
+		dc.Clusters[o.Self] = o
+	case *mo.ResourcePool:
+		dc.ResourcePools[o.Self] = o
+	case *mo.VirtualApp:
+		dc.ResourcePools[o.Self] = &o.ResourcePool
+	case *mo.Network:
+		dc.Networks[o.Self] = o
+	case *mo.DistributedVirtualPortgroup:
+		dc.Networks[o.Self] = &o.Network
+		dc.DistributedPortgroups[o.Self] = o
+	case *mo.OpaqueNetwork:
+		dc.Networks[o.Self] = &o.Network
+	case *mo.StoragePod:
+		dc.StoragePods[o.Self] = o
+	case *mo.DistributedVirtualSwitch:
+		dc.DistributedSwitches[o.Self] = o
+	case *mo.VmwareDistributedVirtualSwitch:
+		dc.DistributedSwitches[o.Self] = &o.DistributedVirtualSwitch
+	}
+}
+
+func removeInventoryObject(dc *model.Datacenter, ref types.ManagedObjectReference) {
+	delete(dc.VirtualMachines, ref)
+	delete(dc.Hosts, ref)
+	delete(dc.Datastores, ref)
+	delete(dc.Clusters, ref)
+	delete(dc.ResourcePools, ref)
+	delete(dc.Networks, ref)
+	delete(dc.StoragePods, ref)
+	delete(dc.DistributedSwitches, ref)
+	delete(dc.DistributedPortgroups, ref)
+}
+
+func (inv *Inventory) collectTags(dc *model.Datacenter) {
+	refs := []types.ManagedObjectReference{dc.Datacenter.Self}
+	refs = append(refs, slices.Collect(maps.Keys(dc.VirtualMachines))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.Hosts))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.Datastores))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.Networks))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.Clusters))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.ResourcePools))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.StoragePods))...)
+	refs = append(refs, slices.Collect(maps.Keys(dc.DistributedSwitches))...)
+
+	_, err := inv.config.TagCollector.FetchTagsForObjects(refs)
+	if err != nil {
+		inv.config.Logrus.WithError(err).WithField("datacenter", dc.Datacenter.Name).Warn("failed to retrieve tags")
+	}
+}
+
+func (inv *Inventory) collectPerfMetrics(dc *model.Datacenter) {
+	definition := inv.config.PerfCollector.MetricDefinition
+
+	collectPerfMetrics(inv.config, dc, slices.Collect(maps.Keys(dc.VirtualMachines)), definition.VM, performance.RealTimeInterval)
+	collectPerfMetrics(inv.config, dc, slices.Collect(maps.Keys(dc.Hosts)), definition.Host, performance.RealTimeInterval)
+	collectPerfMetrics(inv.config, dc, slices.Collect(maps.Keys(dc.Datastores)), definition.Datastore, performance.FiveMinutesInterval)
+	collectPerfMetrics(inv.config, dc, slices.Collect(maps.Keys(dc.Clusters)), definition.ClusterComputeResource, performance.FiveMinutesInterval)
+	collectPerfMetrics(inv.config, dc, slices.Collect(maps.Keys(dc.ResourcePools)), definition.ResourcePool, performance.FiveMinutesInterval)
+}
@@ -0,0 +1,131 @@
+package collect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+
+	logrus "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestInventory(t *testing.T) {
+	c := &config.Config{
+		Logrus: logrus.New(),
+	}
+
+	ctx := context.Background()
+
+	//SettingUp Simulator
+	model := simulator.VPX()
+	defer model.Remove()
+	require.NoError(t, model.Create())
+
+	s := model.Service.NewServer()
+	defer s.Close()
+	var err error
+	c.VMWareClient, err = govmomi.NewClient(ctx, s.URL, true)
+	require.NoError(t, err)
+	c.ViewManager = view.NewManager(c.VMWareClient.Client)
+
+	inv, err := NewInventory(c)
+	require.NoError(t, err)
+	defer inv.Destroy()
+
+	// the first sync fetches the whole inventory
+	require.NoError(t, inv.Sync(false))
+
+	vms := (model.Machine * model.Host) + (model.Machine * model.Cluster)
+	require.Len(t, c.Datacenters, model.Datacenter)
+	dc := c.Datacenters[0]
+	assert.Len(t, dc.Datastores, model.Datastore)
+	assert.Len(t, dc.Hosts, model.Host+model.ClusterHost)
+	assert.Len(t, dc.ResourcePools, 2)
+	assert.Len(t, dc.Clusters, model.Cluster)
+	assert.Len(t, dc.Networks, 3)
+	assert.Len(t, dc.VirtualMachines, vms)
+	assert.Len(t, dc.DistributedSwitches, 1)
+	assert.Len(t, dc.DistributedPortgroups, 2)
+	assert.Len(t, dc.DistributedPortCounts, 3)
+
+	finder := find.NewFinder(c.VMWareClient.Client).SetDatacenter(object.NewDatacenter(c.VMWareClient.Client, dc.Datacenter.Self))
+	vm, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+	require.NoError(t, err)
+	require.Equal(t, types.VirtualMachinePowerStatePoweredOn, dc.VirtualMachines[vm.Reference()].Runtime.PowerState)
+
+	// changes are applied to the objects already collected
+	task, err := vm.PowerOff(ctx)
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, inv.Sync(false))
+	assert.Len(t, dc.VirtualMachines, vms)
+	assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, dc.VirtualMachines[vm.Reference()].Runtime.PowerState)
+	assert.Empty(t, inv.reconfigured, "only vms reconfigured get their storage policies queried again")
+
+	// reconfigured vms are tracked
+	task, err = vm.Reconfigure(ctx, types.VirtualMachineConfigSpec{Annotation: "reconfigured"})
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, inv.Sync(false))
+	assert.Equal(t, "reconfigured", dc.VirtualMachines[vm.Reference()].Config.Annotation)
+	assert.Equal(t, map[types.ManagedObjectReference]bool{vm.Reference(): true}, inv.reconfigured)
+
+	// removed objects are removed from the datacenter
+	task, err = vm.Destroy(ctx)
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, inv.Sync(false))
+	assert.Len(t, dc.VirtualMachines, vms-1)
+	assert.NotContains(t, dc.VirtualMachines, vm.Reference())
+
+	// no changes since the previous sync
+	require.NoError(t, inv.Sync(false))
+	assert.Len(t, dc.VirtualMachines, vms-1)
+
+	// a full sync fetches the whole inventory again
+	require.NoError(t, inv.Sync(true))
+	assert.Len(t, dc.VirtualMachines, vms-1)
+	assert.Len(t, dc.Hosts, model.Host+model.ClusterHost)
+
+	// an update of an object not in the inventory is not applied, the whole inventory is fetched again instead
+	host, err := finder.HostSystem(ctx, "DC0_H0")
+	require.NoError(t, err)
+	delete(inv.objects, host.Reference())
+	delete(dc.Hosts, host.Reference())
+	inv.applyUpdate(dc, types.ObjectUpdate{
+		Kind:      types.ObjectUpdateKindModify,
+		Obj:       host.Reference(),
+		ChangeSet: []types.PropertyChange{{Name: "runtime.inMaintenanceMode", Op: types.PropertyChangeOpAssign, Val: true}},
+	})
+	assert.True(t, inv.stale)
+	assert.NotContains(t, dc.Hosts, host.Reference())
+
+	require.NoError(t, inv.Sync(false))
+	assert.False(t, inv.stale)
+	require.Contains(t, dc.Hosts, host.Reference())
+	assert.NotNil(t, dc.Hosts[host.Reference()].Summary.Hardware)
+
+	// datacenters added later are collected
+	_, err = object.NewRootFolder(c.VMWareClient.Client).CreateDatacenter(ctx, "DC1")
+	require.NoError(t, err)
+
+	require.NoError(t, inv.Sync(false))
+	assert.False(t, inv.datacentersChanged)
+	require.Len(t, c.Datacenters, model.Datacenter+1)
+	for _, d := range c.Datacenters {
+		if d.Datacenter.Name == "DC0" {
+			assert.Len(t, d.VirtualMachines, vms-1)
+		}
+	}
+}
@@ -11,13 +11,15 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 )
 
-// Networks ESXi
+// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.Network.html
+var networkProperties = []string{"name", "summary", "overallStatus", "host", "vm"}
+
+// Networks collects data of all networks
 func Networks(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.Network.html
-	propertiesToRetrieve := []string{"name"}
+	propertiesToRetrieve := networkProperties
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -39,6 +41,17 @@ func Networks(config *config.Config) {
 			logger.WithError(err).Error("failed to retrieve Networks")
 			continue
 		}
+
+		// collect (and cache) the objects tags in bulk
+		if config.TagCollectionEnabled() {
+			_, err = config.TagCollector.FetchTagsForObjects(networks)
+			if err != nil {
+				logger.WithError(err).Warn("failed to retrieve tags for networks")
+			} else {
+				logger.WithField("seconds", config.Uptime()).Debug("networks tags collected")
+			}
+		}
+
 		for j := 0; j < len(networks); j++ {
 			config.Datacenters[i].Networks[networks[j].Self] = &networks[j]
 		}
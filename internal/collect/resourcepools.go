@@ -12,12 +12,14 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+var resourcePoolProperties = []string{"summary", "owner", "parent", "runtime", "name", "overallStatus", "vm", "resourcePool"}
+
 // ResourcePools VMWare
 func ResourcePools(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	propertiesToRetrieve := []string{"summary", "owner", "parent", "runtime", "name", "overallStatus", "vm", "resourcePool"}
+	propertiesToRetrieve := resourcePoolProperties
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
 
@@ -0,0 +1,59 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// Reference: https://code.vmware.com/apis/42/vsphere/doc/vim.StoragePod.html
+var storagePodProperties = []string{"name", "summary", "childEntity", "podStorageDrsEntry", "overallStatus"}
+
+// StoragePods collects data of all datastore clusters
+func StoragePods(config *config.Config) {
+	ctx := context.Background()
+	m := config.ViewManager
+
+	propertiesToRetrieve := storagePodProperties
+	for i, dc := range config.Datacenters {
+		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+		cv, err := m.CreateContainerView(ctx, dc.Datacenter.Reference(), []string{STORAGE_POD}, true)
+		if err != nil {
+			logger.WithError(err).Error("failed to create StoragePod container view")
+			continue
+		}
+		defer func() {
+			err := cv.Destroy(ctx)
+			if err != nil {
+				logger.WithError(err).Error("error while cleaning up storage pods container view")
+			}
+		}()
+
+		var pods []mo.StoragePod
+		err = cv.Retrieve(ctx, []string{STORAGE_POD}, propertiesToRetrieve, &pods)
+		if err != nil {
+			logger.WithError(err).Error("failed to retrieve StoragePods")
+			continue
+		}
+
+		// collect (and cache) the objects tags in bulk
+		if config.TagCollectionEnabled() {
+			_, err = config.TagCollector.FetchTagsForObjects(pods)
+			if err != nil {
+				logger.WithError(err).Warn("failed to retrieve tags for storage pods")
+			} else {
+				logger.WithField("seconds", config.Uptime()).Debug("storage pods tags collected")
+			}
+		}
+
+		for j, pod := range pods {
+			config.Datacenters[i].StoragePods[pod.Self] = &pods[j]
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/pbm"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// storagePolicyBatchSizeDefault is the number of virtual disks queried at once when the argument is not valid
+const storagePolicyBatchSizeDefault = 500
+
+// collectVirtualDiskStoragePolicies fetches the name of the storage policy associated to each virtual disk of
+// the given datacenter vms, or of every vm if nil. The policies of the other vms are kept, so only the vms
+// reconfigured since the previous collection need to be queried. Storage policies are only available through the
+// vCenter storage policy service.
+func collectVirtualDiskStoragePolicies(config *config.Config, dc *model.Datacenter, vms map[types.ManagedObjectReference]bool) {
+	if !config.IsVcenterAPIType {
+		return
+	}
+	ctx := context.Background()
+	logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+	// policies of the vms removed are dropped along the way
+	policies := make(map[types.ManagedObjectReference]map[int32]string)
+	var disks []pbmtypes.PbmServerObjectRef
+	for ref, vm := range dc.VirtualMachines {
+		if vms != nil && !vms[ref] {
+			if vmPolicies, ok := dc.VirtualDiskStoragePolicies[ref]; ok {
+				policies[ref] = vmPolicies
+			}
+			continue
+		}
+		if vm.Config == nil {
+			continue
+		}
+		for _, device := range vm.Config.Hardware.Device {
+			if disk, ok := device.(*types.VirtualDisk); ok {
+				disks = append(disks, pbmtypes.PbmServerObjectRef{
+					ObjectType: string(pbmtypes.PbmObjectTypeVirtualDiskId),
+					Key:        fmt.Sprintf("%s:%d", vm.Self.Value, disk.Key),
+				})
+			}
+		}
+	}
+
+	defer func() {
+		dc.VirtualDiskStoragePolicies = policies
+	}()
+	if len(disks) == 0 {
+		return
+	}
+
+	c, err := pbm.NewClient(ctx, config.VMWareClient.Client)
+	if err != nil {
+		logger.WithError(err).Warn("failed to create storage policy client")
+		return
+	}
+
+	results := queryAssociatedProfiles(disks, storagePolicyBatchSize(config), func(batch []pbmtypes.PbmServerObjectRef) ([]pbmtypes.PbmQueryProfileResult, error) {
+		return c.QueryAssociatedProfiles(ctx, batch)
+	}, logger)
+
+	var ids []pbmtypes.PbmProfileId
+	seen := make(map[string]bool)
+	for _, result := range results {
+		for _, id := range result.ProfileId {
+			if !seen[id.UniqueId] {
+				seen[id.UniqueId] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	profiles, err := c.RetrieveContent(ctx, ids)
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve storage policies")
+		return
+	}
+	names := make(map[string]string)
+	for _, p := range profiles {
+		profile := p.GetPbmProfile()
+		names[profile.ProfileId.UniqueId] = profile.Name
+	}
+
+	for _, result := range results {
+		if len(result.ProfileId) == 0 {
+			continue
+		}
+		// keys have the format vmMoid:diskKey
+		sep := strings.LastIndex(result.Object.Key, ":")
+		if sep < 0 {
+			continue
+		}
+		diskKey, err := strconv.ParseInt(result.Object.Key[sep+1:], 10, 32)
+		if err != nil {
+			continue
+		}
+		vm := types.ManagedObjectReference{Type: VIRTUAL_MACHINE, Value: result.Object.Key[:sep]}
+		if _, ok := policies[vm]; !ok {
+			policies[vm] = make(map[int32]string)
+		}
+		policies[vm][int32(diskKey)] = names[result.ProfileId[0].UniqueId]
+	}
+}
+
+// queryAssociatedProfiles queries the storage policies associated to the disks in batches, skipping the batches
+// failing
+func queryAssociatedProfiles(disks []pbmtypes.PbmServerObjectRef, batchSize int, query func([]pbmtypes.PbmServerObjectRef) ([]pbmtypes.PbmQueryProfileResult, error), logger *logrus.Entry) []pbmtypes.PbmQueryProfileResult {
+	var results []pbmtypes.PbmQueryProfileResult
+	for i := 0; i < len(disks); i += batchSize {
+		batch, err := query(disks[i:min(i+batchSize, len(disks))])
+		if err != nil {
+			logger.WithError(err).Warn("failed to retrieve virtual disks storage policies")
+			continue
+		}
+		results = append(results, batch...)
+	}
+	return results
+}
+
+// storagePolicyBatchSize returns the number of virtual disks queried at once, or the default one if not valid
+func storagePolicyBatchSize(config *config.Config) int {
+	size, err := strconv.Atoi(config.Args.BatchSizePolicyDisks)
+	if err != nil || size <= 0 {
+		config.Logrus.WithField("batchSizePolicyDisks", config.Args.BatchSizePolicyDisks).Warn("invalid storage policy batch size, using default value")
+		return storagePolicyBatchSizeDefault
+	}
+	return size
+}
@@ -0,0 +1,72 @@
+package collect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+
+	logrus "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_queryAssociatedProfiles_Batches(t *testing.T) {
+	var disks []pbmtypes.PbmServerObjectRef
+	for i := 0; i < 5; i++ {
+		disks = append(disks, pbmtypes.PbmServerObjectRef{Key: string(rune('a' + i))})
+	}
+
+	var batches [][]pbmtypes.PbmServerObjectRef
+	results := queryAssociatedProfiles(disks, 2, func(batch []pbmtypes.PbmServerObjectRef) ([]pbmtypes.PbmQueryProfileResult, error) {
+		batches = append(batches, batch)
+		if len(batches) == 2 {
+			return nil, errors.New("failed")
+		}
+		var results []pbmtypes.PbmQueryProfileResult
+		for _, disk := range batch {
+			results = append(results, pbmtypes.PbmQueryProfileResult{Object: disk})
+		}
+		return results, nil
+	}, logrus.NewEntry(logrus.New()))
+
+	assert.Equal(t, [][]pbmtypes.PbmServerObjectRef{disks[0:2], disks[2:4], disks[4:5]}, batches)
+	// the batch failing is skipped
+	assert.Equal(t, []pbmtypes.PbmQueryProfileResult{{Object: disks[0]}, {Object: disks[1]}, {Object: disks[4]}}, results)
+}
+
+func Test_storagePolicyBatchSize(t *testing.T) {
+	tests := map[string]int{
+		"100": 100,
+		"":    storagePolicyBatchSizeDefault,
+		"0":   storagePolicyBatchSizeDefault,
+		"-1":  storagePolicyBatchSizeDefault,
+		"abc": storagePolicyBatchSizeDefault,
+	}
+	for value, expected := range tests {
+		cfg := &config.Config{Logrus: logrus.New()}
+		cfg.Args.BatchSizePolicyDisks = value
+		assert.Equal(t, expected, storagePolicyBatchSize(cfg), value)
+	}
+}
+
+func Test_collectVirtualDiskStoragePolicies_KeepsUnchangedVms(t *testing.T) {
+	kept := types.ManagedObjectReference{Type: VIRTUAL_MACHINE, Value: "vm-1"}
+	removed := types.ManagedObjectReference{Type: VIRTUAL_MACHINE, Value: "vm-2"}
+
+	dc := model.NewDatacenter(&mo.Datacenter{ManagedEntity: mo.ManagedEntity{Name: "dc"}})
+	dc.VirtualMachines[kept] = &mo.VirtualMachine{}
+	dc.VirtualDiskStoragePolicies = map[types.ManagedObjectReference]map[int32]string{
+		kept:    {2000: "policy"},
+		removed: {2000: "policy"},
+	}
+
+	cfg := &config.Config{Logrus: logrus.New(), IsVcenterAPIType: true}
+	// no vm was reconfigured, so the storage policy service is not queried
+	collectVirtualDiskStoragePolicies(cfg, dc, map[types.ManagedObjectReference]bool{})
+
+	assert.Equal(t, map[types.ManagedObjectReference]map[int32]string{kept: {2000: "policy"}}, dc.VirtualDiskStoragePolicies)
+}
@@ -17,12 +17,7 @@ func VirtualMachines(config *config.Config) {
 	ctx := context.Background()
 	m := config.ViewManager
 
-	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.VirtualMachine.html
-	propertiesToRetrieve := []string{"name", "summary", "network", "config", "guest", "runtime", "resourcePool", "datastore", "overallStatus"}
-	if config.Args.EnableVsphereSnapshots {
-		config.Logrus.Debug("collecting as well snapshot and layoutEx properties")
-		propertiesToRetrieve = append(propertiesToRetrieve, "snapshot", "layoutEx.file", "layoutEx.disk", "layoutEx.snapshot")
-	}
+	propertiesToRetrieve := vmProperties(config)
 
 	for i, dc := range config.Datacenters {
 		logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
@@ -71,6 +66,8 @@ func VirtualMachines(config *config.Config) {
 			vmRefs = append(vmRefs, vm.Self)
 		}
 
+		collectVirtualDiskStoragePolicies(config, dc, nil)
+
 		if config.PerfMetricsCollectionEnabled() {
 			metricsToCollect := config.PerfCollector.MetricDefinition.VM
 			collectedData := config.PerfCollector.Collect(vmRefs, metricsToCollect, performance.RealTimeInterval)
@@ -80,3 +77,13 @@ func VirtualMachines(config *config.Config) {
 		}
 	}
 }
+
+func vmProperties(config *config.Config) []string {
+	// Reference: http://pubs.vmware.com/vsphere-60/topic/com.vmware.wssdk.apiref.doc/vim.VirtualMachine.html
+	propertiesToRetrieve := []string{"name", "summary", "network", "config", "guest", "runtime", "resourcePool", "datastore", "overallStatus"}
+	if config.Args.EnableVsphereSnapshots {
+		config.Logrus.Debug("collecting as well snapshot and layoutEx properties")
+		propertiesToRetrieve = append(propertiesToRetrieve, "snapshot", "layoutEx.file", "layoutEx.disk", "layoutEx.snapshot")
+	}
+	return propertiesToRetrieve
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collect
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vsan"
+	"github.com/vmware/govmomi/vsan/methods"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+// vSAN performance service entity types queried for each cluster, the id of every entity is matched with a wildcard
+const (
+	vsanClusterPerfEntity = "cluster-domclient"
+	vsanHostPerfEntity    = "host-domclient"
+)
+
+// vsanPerfWindow is the time range queried to the vSAN performance service, which samples every 5 minutes
+const vsanPerfWindow = 15 * time.Minute
+
+// vsanHealthFields limits the health summary to the fields reported. The health checks are not run again but
+// retrieved from the vSAN health service cache.
+var vsanHealthFields = []string{"overallHealth", "groups", "physicalDisksHealth"}
+
+var (
+	vsanSpaceReportSystem = types.ManagedObjectReference{
+		Type:  "VsanSpaceReportSystem",
+		Value: "vsan-cluster-space-report-system",
+	}
+	vsanClusterHealthSystem = types.ManagedObjectReference{
+		Type:  "VsanVcClusterHealthSystem",
+		Value: "vsan-cluster-health-system",
+	}
+	vsanObjectSystem = vsan.VsanQueryObjectIdentitiesInstance
+)
+
+// Vsan collects capacity, health, resync and performance data of the vSAN enabled clusters through the vSAN
+// management API exposed by vCenter. Clusters must have been collected already.
+func Vsan(config *config.Config) {
+	if !config.VsanCollectionEnabled() {
+		return
+	}
+	ctx := context.Background()
+
+	c, err := vsan.NewClient(ctx, config.VMWareClient.Client)
+	if err != nil {
+		config.Logrus.WithError(err).Error("failed to create vSAN client")
+		return
+	}
+
+	for _, dc := range config.Datacenters {
+		collectVsanClusters(ctx, config, c, dc)
+	}
+}
+
+func collectVsanClusters(ctx context.Context, config *config.Config, c *vsan.Client, dc *model.Datacenter) {
+	logger := config.Logrus.WithField("datacenter", dc.Datacenter.Name)
+
+	vsanClusters := make(map[types.ManagedObjectReference]*model.VsanCluster)
+	defer func() {
+		dc.VsanClusters = vsanClusters
+	}()
+
+	for _, cluster := range dc.Clusters {
+		if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(cluster.Self) {
+			continue
+		}
+		clusterLogger := logger.WithField("cluster", cluster.Name)
+
+		clusterConfig, err := c.VsanClusterGetConfig(ctx, cluster.Self)
+		if err != nil {
+			clusterLogger.WithError(err).Warn("failed to retrieve vSAN cluster configuration")
+			continue
+		}
+		if clusterConfig.Enabled == nil || !*clusterConfig.Enabled {
+			continue
+		}
+		vc := &model.VsanCluster{}
+
+		space, err := methods.VsanQuerySpaceUsage(ctx, c, &vsantypes.VsanQuerySpaceUsage{
+			This:    vsanSpaceReportSystem,
+			Cluster: cluster.Self,
+		})
+		if err != nil {
+			clusterLogger.WithError(err).Warn("failed to retrieve vSAN space usage")
+		} else {
+			vc.SpaceUsage = &space.Returnval
+		}
+
+		fetchFromCache := true
+		health, err := methods.VsanQueryVcClusterHealthSummary(ctx, c, &vsantypes.VsanQueryVcClusterHealthSummary{
+			This:           vsanClusterHealthSystem,
+			Cluster:        &cluster.Self,
+			Fields:         vsanHealthFields,
+			FetchFromCache: &fetchFromCache,
+		})
+		if err != nil {
+			clusterLogger.WithError(err).Warn("failed to retrieve vSAN health summary")
+		} else {
+			vc.Health = &health.Returnval
+		}
+
+		resync, err := methods.QuerySyncingVsanObjectsSummary(ctx, c, &vsantypes.QuerySyncingVsanObjectsSummary{
+			This:                vsanObjectSystem,
+			Cluster:             cluster.Self,
+			SyncingObjectFilter: &vsantypes.VsanSyncingObjectFilter{NumberOfObjects: 1},
+		})
+		if err != nil {
+			clusterLogger.WithError(err).Warn("failed to retrieve vSAN resync summary")
+		} else {
+			vc.Resync = &resync.Returnval
+		}
+
+		collectVsanPerfMetrics(ctx, clusterLogger, c, dc, cluster.Self, vc)
+
+		vsanClusters[cluster.Self] = vc
+	}
+}
+
+// collectVsanPerfMetrics queries the vSAN performance service keeping the latest value of each counter of the
+// cluster and of its hosts. Hosts are identified by their vSAN node uuid.
+func collectVsanPerfMetrics(ctx context.Context, logger *logrus.Entry, c *vsan.Client, dc *model.Datacenter, cluster types.ManagedObjectReference, vc *model.VsanCluster) {
+	vc.PerfMetrics = make(map[string]float64)
+	vc.HostPerfMetrics = make(map[types.ManagedObjectReference]map[string]float64)
+
+	hostsByNodeUuid := make(map[string]types.ManagedObjectReference)
+	for _, host := range dc.Hosts {
+		if host.Config == nil || host.Config.VsanHostConfig == nil || host.Config.VsanHostConfig.ClusterInfo == nil {
+			continue
+		}
+		hostsByNodeUuid[host.Config.VsanHostConfig.ClusterInfo.NodeUuid] = host.Self
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-vsanPerfWindow)
+	var specs []vsantypes.VsanPerfQuerySpec
+	for _, entity := range []string{vsanClusterPerfEntity, vsanHostPerfEntity} {
+		specs = append(specs, vsantypes.VsanPerfQuerySpec{
+			EntityRefId: entity + ":*",
+			StartTime:   &startTime,
+			EndTime:     &endTime,
+		})
+	}
+
+	results, err := c.VsanPerfQueryPerf(ctx, &cluster, specs)
+	if err != nil {
+		logger.WithError(err).Warn("failed to retrieve vSAN performance metrics")
+		return
+	}
+
+	for _, result := range results {
+		// entity ids have the format entityType:uuid
+		entityType, uuid, found := strings.Cut(result.EntityRefId, ":")
+		if !found {
+			continue
+		}
+		var values map[string]float64
+		switch entityType {
+		case vsanClusterPerfEntity:
+			values = vc.PerfMetrics
+		case vsanHostPerfEntity:
+			host, ok := hostsByNodeUuid[uuid]
+			if !ok {
+				continue
+			}
+			if _, ok := vc.HostPerfMetrics[host]; !ok {
+				vc.HostPerfMetrics[host] = make(map[string]float64)
+			}
+			values = vc.HostPerfMetrics[host]
+		default:
+			continue
+		}
+		for _, series := range result.Value {
+			if value, ok := lastVsanPerfValue(series.Values); ok {
+				values[series.MetricId.Label] = value
+			}
+		}
+	}
+}
+
+// lastVsanPerfValue returns the latest sample of the comma separated values of a vSAN performance series
+func lastVsanPerfValue(csv string) (float64, bool) {
+	samples := strings.Split(csv, ",")
+	for i := len(samples) - 1; i >= 0; i-- {
+		value, err := strconv.ParseFloat(strings.TrimSpace(samples[i]), 64)
+		if err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
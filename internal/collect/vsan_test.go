@@ -0,0 +1,60 @@
+package collect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	_ "github.com/vmware/govmomi/vsan/simulator"
+)
+
+func Test_Vsan_SkipsClustersWithoutVsan(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+
+		cfg := &config.Config{
+			Args:             config.ArgumentList{EnableVsan: true},
+			IsVcenterAPIType: true,
+			VMWareClient:     vmClient,
+			ViewManager:      view.NewManager(vc),
+			Logrus:           logrus.StandardLogger(),
+		}
+		err = Datacenters(cfg)
+		require.NoError(t, err)
+		Clusters(cfg)
+		require.NotEmpty(t, cfg.Datacenters[0].Clusters)
+
+		Vsan(cfg)
+
+		assert.Empty(t, cfg.Datacenters[0].VsanClusters)
+		return nil
+	})
+}
+
+func Test_lastVsanPerfValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		csv    string
+		want   float64
+		wantOk bool
+	}{
+		{name: "LastSample", csv: "1,2.5,3", want: 3, wantOk: true},
+		{name: "SkipsMissingSamples", csv: "1,2,", want: 2, wantOk: true},
+		{name: "Empty", csv: "", want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lastVsanPerfValue(tt.csv)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
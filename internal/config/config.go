@@ -6,7 +6,10 @@ package config
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/newrelic/nri-vsphere/internal/model"
@@ -18,6 +21,7 @@ import (
 	logrus "github.com/sirupsen/logrus"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/view"
+	"gopkg.in/yaml.v2"
 )
 
 // ArgumentList Available Arguments
@@ -32,6 +36,21 @@ type ArgumentList struct {
 
 	EnableVsphereEvents bool   `default:"false" help:"Set to collect vSphere events"`
 	EventsPageSize      string `default:"100" help:"Number of events fetched from the vCenter in each call"`
+	EnableVsphereTasks  bool   `default:"false" help:"Set to collect vSphere tasks along with the events"`
+	TasksPageSize       string `default:"100" help:"Number of tasks fetched from the vCenter in each call"`
+
+	EventsIncludeTypes      string `default:"" help:"Space-separated list of event types to be collected, either type names or EventEx ids. \nExample: --events_include_types VmMigratedEvent com.vmware.vc.HA.HostFailedEvent"`
+	EventsExcludeTypes      string `default:"" help:"Space-separated list of event types not to be collected, either type names or EventEx ids. \nExample: --events_exclude_types UserLoginSessionEvent UserLogoutSessionEvent"`
+	EventsIncludeCategories string `default:"" help:"Space-separated list of event categories to be collected: info, warning, error or user"`
+	EventsExcludeCategories string `default:"" help:"Space-separated list of event categories not to be collected: info, warning, error or user"`
+	EventsIncludeUsers      string `default:"" help:"Space-separated list of usernames whose events are collected. Events not triggered by a user are left out"`
+	EventsExcludeUsers      string `default:"" help:"Space-separated list of usernames whose events are not collected"`
+
+	EnableVsphereAuditEvents bool `default:"false" help:"Set to report login, session, permission, role and account events as vSphereAuditEvent events with normalized actor, source ip, target and action. \nRequires enable_vsphere_events"`
+
+	EventsMaxBackfill      string `default:"1h" help:"Maximum age of the events and tasks fetched when the last ones collected are older, eg. 1h, 30m. \nOlder events are skipped unless enable_events_catch_up is set"`
+	EnableEventsCatchUp    bool   `default:"false" help:"Set to fetch the events and tasks older than events_max_backfill across several runs instead of skipping them"`
+	EventsCatchUpMaxEvents int    `default:"1000" help:"Maximum number of events, and of tasks, fetched in each run when enable_events_catch_up is set"`
 
 	EnableVspherePerfMetrics bool   `default:"false" help:"Set to collect vSphere performance metrics"`
 	PerfLevel                int    `default:"1" help:"Performance counter level of performance metrics that will be collected"`
@@ -44,13 +63,43 @@ type ArgumentList struct {
 	//https://vdc-download.vmware.com/vmwb-repository/dcr-public/cdbbd51c-4824-4a1b-ad43-45df55a76a76/8cb3ed93-cac2-46aa-b329-db5a096af5bc/vsphere-web-services-sdk-67-programming-guide.pdf
 	BatchSizePerfEntities string `default:"50" help:"Number of entities requested at the same time when querying performance metrics"`
 	BatchSizePerfMetrics  string `default:"50" help:"Number of metrics requested at the same time when querying performance metrics"`
+	BatchSizePolicyDisks  string `default:"500" help:"Number of virtual disks requested at the same time when querying storage policies"`
+
+	EnablePerfInstanceSamples bool `default:"false" help:"Set to report the value of each instance of the performance counters, such as a cpu core or a vmnic, in a VSphere<Entity>PerfInstanceSample. \nRequires enable_vsphere_perf_metrics"`
 
 	EnableVsphereTags      bool `default:"false" help:"Set to collect tags. Tags are available when connecting to vcenter"`
 	EnableVsphereSnapshots bool `default:"false" help:"Set to collect and process VMs Snapshots data"`
+	EnableVsan             bool `default:"false" help:"Set to collect vSAN capacity, health, resync and performance data of vSAN clusters and hosts. vSAN data is available when connecting to vcenter"`
+	EnableVsphereAlarms    bool `default:"false" help:"Set to report the alarms currently triggered on each entity as VSphereTriggeredAlarmSample"`
 	ValidateSSL            bool `default:"false" help:"Set to validates SSL when connecting to vCenter or Esxi Host"`
 	ShowVersion            bool `default:"false" help:"Print build information and exit"`
 
 	IncludeTags string `default:"" help:"Space-separated list of tag categories and values for resource inclusion. \nIf defined, only resources tagged with any of the tags will be included in the results. \nYou must also include 'enable_vsphere_tags' in order for this option to work. \nExample: --include_tags env=prod dc=eu"`
+
+	EndpointsFile string `default:"" help:"Location of a YAML file listing multiple vCenter or ESXi endpoints to be collected concurrently. \nIf defined, url, user, pass, validate_ssl and datacenter_location are taken from the file for each endpoint"`
+
+	EnableSessionReuse bool `default:"false" help:"Set to keep the sessions open at exit and reuse them in the following executions. \nSessions are stored encrypted in the integrations persist directory and renewed only once expired. \nStored sessions grant the same access as the credentials until they expire"`
+
+	EnableDaemonMode           bool   `default:"false" help:"Set to keep running, holding the sessions open, and collect inventory, performance metrics, events and tags on independent intervals"`
+	EnableIncrementalInventory bool   `default:"false" help:"Set to collect the inventory incrementally. Implies daemon mode. \nOnly objects changed since the previous collection are fetched from the vCenter or ESXi host"`
+	InventoryInterval          string `default:"60s" help:"Interval between inventory collections in daemon mode eg. 30s, 5m"`
+	PerfInterval               string `default:"20s" help:"Interval between performance metrics collections in daemon mode"`
+	EventsInterval             string `default:"60s" help:"Interval between events collections in daemon mode"`
+	TagsInterval               string `default:"1h" help:"Interval between tags collections in daemon mode"`
+}
+
+// Endpoint holds the connection data of a single vCenter or ESXi host listed in the endpoints file
+type Endpoint struct {
+	URL                string `yaml:"url"`
+	User               string `yaml:"user"`
+	Pass               string `yaml:"pass"`
+	ValidateSSL        bool   `yaml:"validate_ssl"`
+	DatacenterLocation string `yaml:"datacenter_location"`
+}
+
+// This struct is used to parse the endpoints file
+type endpointsConfig struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
 }
 
 type Config struct {
@@ -68,7 +117,11 @@ type Config struct {
 	Datacenters          []*model.Datacenter      // Datacenters VMWare
 	IsVcenterAPIType     bool                     // IsVcenterAPIType true if connecting to vcenter
 	PerfCollector        *performance.PerfCollector
+	EndpointID           string    // EndpointID identifies the endpoint when collecting multiple ones, empty otherwise
 	startTime            time.Time // start time the integration started.
+
+	entitiesLock sync.Mutex
+	entities     map[string]bool // keys of the entities reported by the endpoint in the last samples created
 }
 
 func New(buildVersion string) *Config {
@@ -99,6 +152,22 @@ func (c *Config) EventCollectionEnabled() bool {
 	return c.IsVcenterAPIType && c.Args.EnableVsphereEvents
 }
 
+func (c *Config) AuditEventsEnabled() bool {
+	return c.EventCollectionEnabled() && c.Args.EnableVsphereAuditEvents
+}
+
+func (c *Config) TaskCollectionEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableVsphereTasks
+}
+
+func (c *Config) VsanCollectionEnabled() bool {
+	return c.IsVcenterAPIType && c.Args.EnableVsan
+}
+
+func (c *Config) AlarmCollectionEnabled() bool {
+	return c.Args.EnableVsphereAlarms
+}
+
 func (c *Config) TagFilteringEnabled() bool {
 	return c.TagCollectionEnabled() && len(c.Args.IncludeTags) > 0
 }
@@ -107,6 +176,116 @@ func (c *Config) PerfMetricsCollectionEnabled() bool {
 	return c.Args.EnableVspherePerfMetrics
 }
 
+func (c *Config) PerfInstanceSamplesEnabled() bool {
+	return c.PerfMetricsCollectionEnabled() && c.Args.EnablePerfInstanceSamples
+}
+
 func (c *Config) Uptime() time.Duration {
 	return time.Since(c.startTime)
 }
+
+func (c *Config) MultiEndpointEnabled() bool {
+	return c.Args.EndpointsFile != ""
+}
+
+func (c *Config) IncrementalInventoryEnabled() bool {
+	return c.Args.EnableIncrementalInventory
+}
+
+func (c *Config) SessionReuseEnabled() bool {
+	return c.Args.EnableSessionReuse
+}
+
+func (c *Config) DaemonModeEnabled() bool {
+	return c.Args.EnableDaemonMode || c.IncrementalInventoryEnabled()
+}
+
+// LoadEndpoints parses the endpoints file returning the list of endpoints to be collected
+func LoadEndpoints(fileName string) ([]Endpoint, error) {
+	endpointsFile, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error loading endpoints from file: %v", err)
+	}
+	defer endpointsFile.Close()
+
+	var ec endpointsConfig
+	err = yaml.NewDecoder(endpointsFile).Decode(&ec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing endpoints file: %v", err)
+	}
+
+	// datacenters, clusters and other objects without a unique id are reported by name, prefixed by the location,
+	// so each endpoint needs its own location for their entities not to be merged
+	locations := make(map[string]int)
+	for i, e := range ec.Endpoints {
+		if e.URL == "" || e.User == "" || e.Pass == "" {
+			return nil, fmt.Errorf("endpoint %d is missing one of the required fields url, user or pass", i)
+		}
+		location := strings.ToLower(e.DatacenterLocation)
+		if len(ec.Endpoints) > 1 {
+			if location == "" {
+				return nil, fmt.Errorf("endpoint %d is missing the datacenter_location, required when listing multiple endpoints", i)
+			}
+			if j, ok := locations[location]; ok {
+				return nil, fmt.Errorf("endpoints %d and %d have the same datacenter_location %q", j, i, location)
+			}
+			locations[location] = i
+		}
+		ec.Endpoints[i].DatacenterLocation = location
+	}
+	if len(ec.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoint defined in file %s", fileName)
+	}
+	return ec.Endpoints, nil
+}
+
+// AddEntity records an entity reported by the endpoint. The entities of the integration are shared by every
+// endpoint, so the ones of the endpoint are kept apart to be looked up while other endpoints are still adding theirs.
+func (c *Config) AddEntity(key string) {
+	c.entitiesLock.Lock()
+	defer c.entitiesLock.Unlock()
+	if c.entities == nil {
+		c.entities = make(map[string]bool)
+	}
+	c.entities[key] = true
+}
+
+// HasEntity returns true if the entity was reported by the endpoint since the last call to ResetEntities
+func (c *Config) HasEntity(key string) bool {
+	c.entitiesLock.Lock()
+	defer c.entitiesLock.Unlock()
+	return c.entities[key]
+}
+
+// ResetEntities forgets the entities reported by the endpoint, before creating the samples again
+func (c *Config) ResetEntities() {
+	c.entitiesLock.Lock()
+	defer c.entitiesLock.Unlock()
+	c.entities = nil
+}
+
+// ForEndpoint returns a copy of the config pointing to the given endpoint. Clients, collectors and collected
+// data are not shared, while the integration, the logger and the remaining arguments are.
+func (c *Config) ForEndpoint(e Endpoint) *Config {
+	ec := &Config{
+		Args:                 c.Args,
+		Integration:          c.Integration,
+		Hostname:             c.Hostname,
+		Logrus:               c.Logrus,
+		IntegrationName:      c.IntegrationName,
+		IntegrationNameShort: c.IntegrationNameShort,
+		IntegrationVersion:   c.IntegrationVersion,
+		startTime:            c.startTime,
+	}
+	ec.Args.URL = e.URL
+	ec.Args.User = e.User
+	ec.Args.Pass = e.Pass
+	ec.Args.ValidateSSL = e.ValidateSSL
+	ec.Args.DatacenterLocation = e.DatacenterLocation
+
+	ec.EndpointID = e.URL
+	if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+		ec.EndpointID = u.Host
+	}
+	return ec
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEndpoints(t *testing.T) {
+	content := []byte(`
+endpoints:
+  - url: https://vcenter-1:8989/sdk
+    user: user1
+    pass: pass1
+    validate_ssl: true
+    datacenter_location: Sydney
+  - url: https://vcenter-2/sdk
+    user: user2
+    pass: pass2
+    datacenter_location: london
+`)
+	tmpfile, err := os.CreateTemp("", "endpoints")
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(tmpfile.Name())
+		assert.NoError(t, err)
+	}()
+	_, err = tmpfile.Write(content)
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	endpoints, err := LoadEndpoints(tmpfile.Name())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "https://vcenter-1:8989/sdk", endpoints[0].URL)
+	assert.True(t, endpoints[0].ValidateSSL)
+	assert.Equal(t, "sydney", endpoints[0].DatacenterLocation)
+	assert.False(t, endpoints[1].ValidateSSL)
+	assert.Equal(t, "london", endpoints[1].DatacenterLocation)
+}
+
+func TestLoadEndpoints_DatacenterLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "single endpoint without location",
+			content: `
+endpoints:
+  - {url: https://vcenter-1/sdk, user: user1, pass: pass1}
+`,
+		},
+		{
+			name: "multiple endpoints with a missing location",
+			content: `
+endpoints:
+  - {url: https://vcenter-1/sdk, user: user1, pass: pass1, datacenter_location: sydney}
+  - {url: https://vcenter-2/sdk, user: user2, pass: pass2}
+`,
+			wantErr: true,
+		},
+		{
+			name: "multiple endpoints with the same location",
+			content: `
+endpoints:
+  - {url: https://vcenter-1/sdk, user: user1, pass: pass1, datacenter_location: sydney}
+  - {url: https://vcenter-2/sdk, user: user2, pass: pass2, datacenter_location: Sydney}
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpfile, err := os.CreateTemp("", "endpoints")
+			require.NoError(t, err)
+			defer func() {
+				err := os.Remove(tmpfile.Name())
+				assert.NoError(t, err)
+			}()
+			_, err = tmpfile.Write([]byte(tt.content))
+			require.NoError(t, err)
+			tmpfile.Close()
+
+			_, err = LoadEndpoints(tmpfile.Name())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadEndpoints_MissingFields(t *testing.T) {
+	content := []byte(`
+endpoints:
+  - url: https://vcenter-1/sdk
+    user: user1
+`)
+	tmpfile, err := os.CreateTemp("", "endpoints")
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(tmpfile.Name())
+		assert.NoError(t, err)
+	}()
+	_, err = tmpfile.Write(content)
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	_, err = LoadEndpoints(tmpfile.Name())
+	assert.Error(t, err)
+
+	_, err = LoadEndpoints("not-existing-file")
+	assert.Error(t, err)
+}
+
+func TestForEndpoint(t *testing.T) {
+	cfg := New("0.0.0")
+	cfg.Args.URL = "https://ignored/sdk"
+	cfg.Args.EnableVsphereEvents = true
+
+	ec := cfg.ForEndpoint(Endpoint{
+		URL:                "https://vcenter-1:8989/sdk",
+		User:               "user",
+		Pass:               "pass",
+		DatacenterLocation: "sydney",
+	})
+
+	assert.Equal(t, "https://vcenter-1:8989/sdk", ec.Args.URL)
+	assert.Equal(t, "sydney", ec.Args.DatacenterLocation)
+	assert.True(t, ec.Args.EnableVsphereEvents)
+	assert.Equal(t, "vcenter-1:8989", ec.EndpointID)
+	assert.Equal(t, cfg.Logrus, ec.Logrus)
+	// the original config is not modified
+	assert.Equal(t, "https://ignored/sdk", cfg.Args.URL)
+}
@@ -14,57 +14,96 @@ import (
 )
 
 type EventDispacher struct {
+	manager   *event.Manager
 	collector *event.HistoryCollector
 	ctx       *context.Context
 
 	LastTimestamp *time.Time
-	Events        []types.BaseEvent
-	log           *logrus.Logger
-	c             cache.CacheInterface
+	// LastKey holds the key of the last event read, 0 if unknown. Event keys are assigned in increasing order by
+	// the vCenter, so the events sharing the second of the last timestamp are told apart by their key.
+	LastKey int32
+	Events  []types.BaseEvent
+	// Severities holds the category of each event collected, such as "info" or "error", by event key
+	Severities map[int32]string
+	options    Options
+	// lastRead holds the timestamp and key of the last event read, including the ones filtered out
+	lastReadTimestamp time.Time
+	lastReadKey       int32
+	log               *logrus.Logger
+	c                 cache.CacheInterface
+}
+
+// Options holds the settings of the event collection
+type Options struct {
+	Filter *EventFilter
+	// MaxBackfill limits how far back events are fetched when the last ones collected are older
+	MaxBackfill time.Duration
+	// CatchUp fetches the events older than MaxBackfill instead of skipping them, up to MaxEvents in each run
+	CatchUp   bool
+	MaxEvents int
 }
 
 const (
-	pageSizeDefault = 200
+	pageSizeDefault    = 200
+	maxBackfillDefault = time.Hour
 )
 
-func NewEventDispacher(client *vim25.Client, mo types.ManagedObjectReference, log *logrus.Logger, c cache.CacheInterface) (*EventDispacher, error) {
+// NewEventDispacher creates a collector for the events of the entity and its descendants since the last ones
+// collected. The filter, if any, is pushed down to the collector where supported.
+func NewEventDispacher(client *vim25.Client, mo types.ManagedObjectReference, log *logrus.Logger, c cache.CacheInterface, options Options) (*EventDispacher, error) {
 
 	manager := event.NewManager(client)
 	ctx := context.Background()
 
 	now := time.Now()
 	lastTimestamp, err := c.ReadTimestampCache()
-	lastTimestamp = sanitizeTimestamp(err, log, lastTimestamp, now)
-
-	log.WithField("lastTimestamp", lastTimestamp.String()).Debug("Creating collector for events")
-	collector, err := manager.CreateCollectorForEvents(ctx,
-		types.EventFilterSpec{
-			Time: &types.EventFilterSpecByTime{
-				BeginTime: &lastTimestamp,
-				EndTime:   &now,
-			},
-			Entity: &types.EventFilterSpecByEntity{
-				Recursion: types.EventFilterSpecRecursionOptionAll,
-				Entity:    mo,
-			},
+	lastKey, keyErr := c.ReadKeyCache()
+	if err == nil && keyErr != nil {
+		//the key was not stored by previous versions, we are interested into the events logged since 1 second
+		//after the last one retrieved
+		lastTimestamp = lastTimestamp.Add(time.Duration(1) * time.Second)
+		lastKey = 0
+	}
+	lastTimestamp = sanitizeTimestamp(err, log, lastTimestamp, now, options.MaxBackfill, options.CatchUp)
+
+	log.WithField("lastTimestamp", lastTimestamp.String()).WithField("lastKey", lastKey).Debug("Creating collector for events")
+	spec := types.EventFilterSpec{
+		Time: &types.EventFilterSpecByTime{
+			BeginTime: &lastTimestamp,
+			EndTime:   &now,
+		},
+		Entity: &types.EventFilterSpecByEntity{
+			Recursion: types.EventFilterSpecRecursionOptionAll,
+			Entity:    mo,
 		},
-	)
+	}
+	options.Filter.apply(&spec)
+
+	collector, err := manager.CreateCollectorForEvents(ctx, spec)
 	if err != nil {
 		return nil, fmt.Errorf("error while creating historyCollector: %s ", err.Error())
 	}
 
 	ed := EventDispacher{
-		LastTimestamp: &lastTimestamp,
-		collector:     collector,
-		ctx:           &ctx,
-		Events:        []types.BaseEvent{},
-		log:           log,
-		c:             c,
+		manager:           manager,
+		LastTimestamp:     &lastTimestamp,
+		LastKey:           lastKey,
+		collector:         collector,
+		ctx:               &ctx,
+		Events:            []types.BaseEvent{},
+		Severities:        map[int32]string{},
+		options:           options,
+		lastReadTimestamp: lastTimestamp,
+		lastReadKey:       lastKey,
+		log:               log,
+		c:                 c,
 	}
 	return &ed, nil
 }
 
-func sanitizeTimestamp(err error, log *logrus.Logger, lastTimestamp time.Time, now time.Time) time.Time {
+// sanitizeTimestamp returns the time events are fetched from: the last timestamp collected, limited to maxBackfill
+// ago unless catching up, and never after now
+func sanitizeTimestamp(err error, log *logrus.Logger, lastTimestamp time.Time, now time.Time, maxBackfill time.Duration, catchUp bool) time.Time {
 
 	if err != nil {
 		log.WithError(err).Debug("Error reading cache, setting default timestamp to current time")
@@ -72,13 +111,19 @@ func sanitizeTimestamp(err error, log *logrus.Logger, lastTimestamp time.Time, n
 		return lastTimestamp
 	}
 
-	//we are interested into the events logged since 1 second after the last one retrieved
-	lastTimestamp = lastTimestamp.Add(time.Duration(1) * time.Second)
-
-	limitTimestamp := now.Add(time.Duration(-1) * time.Hour)
+	if maxBackfill <= 0 {
+		maxBackfill = maxBackfillDefault
+	}
+	limitTimestamp := now.Add(-maxBackfill)
 	if lastTimestamp.Before(limitTimestamp) {
+		if catchUp {
+			//the backlog is fetched across several runs, a limited number of events each time
+			log.WithField("timestamp", lastTimestamp.String()).Info("Timestamp older than the max backfill, catching up")
+			return lastTimestamp
+		}
 		//we try to avoid a deadlock where tue to a really old timestamp the integration try to fetch too many events timing out
-		log.WithField("timestamp", lastTimestamp.String()).Debug("Timestamp is too old, setting lastTimestamp to 1 hour ago to fetch events")
+		log.WithField("timestamp", lastTimestamp.String()).WithField("maxBackfill", maxBackfill.String()).
+			Warn("Timestamp is older than the max backfill, events before it are skipped")
 		lastTimestamp = limitTimestamp
 		return lastTimestamp
 	}
@@ -90,30 +135,29 @@ func sanitizeTimestamp(err error, log *logrus.Logger, lastTimestamp time.Time, n
 		return lastTimestamp
 	}
 
-	//we are interested into the events logged since 1 second after the last one retrieved
 	return lastTimestamp
 }
 
+// Cancel destroys the collector and stores the timestamp and key of the last event read, so the next collection
+// starts right after it
 func (ed *EventDispacher) Cancel() {
 	err := ed.collector.Destroy(*ed.ctx)
 	if err != nil {
-		ed.log.WithError(err).Error("error while saving cache")
+		ed.log.WithError(err).Error("error while destroying event collector")
 	}
-	t := ed.LastTimestamp
 
-	//computing last timestamp processed
-	for _, e := range ed.Events {
-		if t.Before(e.GetEvent().CreatedTime) {
-			t = &e.GetEvent().CreatedTime
-		}
+	t := ed.lastReadTimestamp
+	ed.log.WithField("date", t).WithField("key", ed.lastReadKey).Debug("saving in cache last read message")
+	err = ed.c.WriteTimestampCache(t)
+	if err != nil {
+		ed.log.WithError(err).Error("error while saving cache")
 	}
-
-	ed.log.WithField("date", t).Debug("saving in cache last read message")
-	err = ed.c.WriteTimestampCache(*t)
+	err = ed.c.WriteKeyCache(ed.lastReadKey)
 	if err != nil {
 		ed.log.WithError(err).Error("error while saving cache")
 	}
-	ed.LastTimestamp = t
+	ed.LastTimestamp = &t
+	ed.LastKey = ed.lastReadKey
 }
 
 func (ed *EventDispacher) CollectEvents(eventsPageSize string) {
@@ -126,18 +170,89 @@ func (ed *EventDispacher) CollectEvents(eventsPageSize string) {
 		pageSize = pageSizeDefault
 	}
 
+	read := 0
 	for {
-		eventsCollected, err := ed.collector.ReadNextEvents(*ed.ctx, int32(pageSize))
+		// when catching up no more than MaxEvents are read, the rest are left for the following runs
+		size := pageSize
+		if ed.options.CatchUp && ed.options.MaxEvents > 0 {
+			if read >= ed.options.MaxEvents {
+				ed.log.WithField("number", read).Info("max events read while catching up, the rest are fetched in the next run")
+				break
+			}
+			if left := ed.options.MaxEvents - read; left < size {
+				size = left
+			}
+		}
+
+		eventsCollected, err := ed.collector.ReadNextEvents(*ed.ctx, int32(size))
 		if err != nil {
 			ed.log.WithError(err).Error("error while fetching events")
 			break
 		}
-		ed.Events = append(ed.Events, eventsCollected...)
+		read += len(eventsCollected)
+		ed.addEvents(eventsCollected)
 		ed.log.WithField("number", len(eventsCollected)).Debug("readNextEventsExecuted")
 
 		//There are no events left if: no events has been collected or if the number of events is smaller than the pagSize
-		if len(eventsCollected) == 0 || len(eventsCollected) != pageSize {
+		if len(eventsCollected) == 0 || len(eventsCollected) != size {
 			break
 		}
 	}
+	ed.categorizeEvents()
+	ed.filterEvents()
+}
+
+// addEvents appends the events not read yet, dropping those sharing the second of the last timestamp that were
+// already read in the previous collection, and keeps track of the last one
+func (ed *EventDispacher) addEvents(events []types.BaseEvent) {
+	for _, be := range events {
+		if be == nil {
+			continue
+		}
+		e := be.GetEvent()
+		if ed.LastKey != 0 && e.Key <= ed.LastKey {
+			continue
+		}
+		ed.Events = append(ed.Events, be)
+
+		if e.Key > ed.lastReadKey {
+			ed.lastReadKey = e.Key
+		}
+		if ed.lastReadTimestamp.Before(e.CreatedTime) {
+			ed.lastReadTimestamp = e.CreatedTime
+		}
+	}
+}
+
+// filterEvents removes the events not matching the filter, either because it could not be pushed down or because
+// the vCenter does not support it
+func (ed *EventDispacher) filterEvents() {
+	if ed.options.Filter == nil {
+		return
+	}
+	var filtered []types.BaseEvent
+	for _, e := range ed.Events {
+		if ed.options.Filter.matches(e, ed.Severities[e.GetEvent().Key]) {
+			filtered = append(filtered, e)
+			continue
+		}
+		delete(ed.Severities, e.GetEvent().Key)
+	}
+	ed.log.WithField("number", len(ed.Events)-len(filtered)).Debug("events filtered out")
+	ed.Events = filtered
+}
+
+// categorizeEvents fetches the severity of the events collected, which for all but EventEx is only available
+// in the description of the event types held by the EventManager
+func (ed *EventDispacher) categorizeEvents() {
+	for _, e := range ed.Events {
+		category, err := ed.manager.EventCategory(*ed.ctx, e)
+		if err != nil {
+			ed.log.WithError(err).Warn("error while fetching event categories")
+			return
+		}
+		if category != "" {
+			ed.Severities[e.GetEvent().Key] = category
+		}
+	}
 }
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Categories of the events, as reported by the EventManager description and the severity of EventEx
+var eventCategories = []string{"info", "warning", "error", "user"}
+
+// EventFilter holds the event types, categories and usernames to be included or excluded. Types are matched against
+// the name of the event type, such as UserLoginSessionEvent, and the eventTypeId of EventEx and ExtendedEvent events,
+// such as com.vmware.vc.HA.HostFailedEvent.
+type EventFilter struct {
+	IncludeTypes      []string
+	ExcludeTypes      []string
+	IncludeCategories []string
+	ExcludeCategories []string
+	IncludeUsers      []string
+	ExcludeUsers      []string
+}
+
+// NewEventFilter returns a filter from space-separated lists of values
+func NewEventFilter(includeTypes, excludeTypes, includeCategories, excludeCategories, includeUsers, excludeUsers string) *EventFilter {
+	return &EventFilter{
+		IncludeTypes:      strings.Fields(includeTypes),
+		ExcludeTypes:      strings.Fields(excludeTypes),
+		IncludeCategories: strings.Fields(strings.ToLower(includeCategories)),
+		ExcludeCategories: strings.Fields(strings.ToLower(excludeCategories)),
+		IncludeUsers:      strings.Fields(includeUsers),
+		ExcludeUsers:      strings.Fields(excludeUsers),
+	}
+}
+
+// EventTypeName returns the name of the vSphere event type, such as VmMigratedEvent
+func EventTypeName(be types.BaseEvent) string {
+	t := reflect.TypeOf(be)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// apply pushes down to the spec the part of the filter supported by the HistoryCollector, which only accepts the
+// values to be included. Exclusions, except for the categories, are left to matches.
+func (f *EventFilter) apply(spec *types.EventFilterSpec) {
+	if f == nil {
+		return
+	}
+
+	if len(f.IncludeTypes) > 0 {
+		var names, ids []string
+		for _, t := range f.IncludeTypes {
+			if isEventTypeId(t) {
+				ids = append(ids, t)
+			} else {
+				names = append(names, t)
+			}
+		}
+		switch {
+		case len(ids) == 0:
+			spec.Type = names
+		case len(names) == 0:
+			spec.Type = []string{"EventEx", "ExtendedEvent"}
+			spec.EventTypeId = ids
+		default:
+			// the ids can only be matched when fetching EventEx and ExtendedEvent events of any id
+			spec.Type = append(names, "EventEx", "ExtendedEvent")
+		}
+	}
+
+	// categories are a closed set, so the excluded ones can be pushed down as the remaining ones
+	categories := f.IncludeCategories
+	if len(categories) == 0 && len(f.ExcludeCategories) > 0 {
+		categories = eventCategories
+	}
+	for _, c := range categories {
+		if !contains(f.ExcludeCategories, c) {
+			spec.Category = append(spec.Category, c)
+		}
+	}
+
+	if len(f.IncludeUsers) > 0 {
+		spec.UserName = &types.EventFilterSpecByUsername{
+			SystemUser: false,
+			UserList:   f.IncludeUsers,
+		}
+	}
+}
+
+// matches returns whether the event passes the filter. The category is only checked when known.
+func (f *EventFilter) matches(be types.BaseEvent, category string) bool {
+	if f == nil {
+		return true
+	}
+
+	names := []string{EventTypeName(be)}
+	switch e := be.(type) {
+	case *types.EventEx:
+		names = append(names, e.EventTypeId)
+	case *types.ExtendedEvent:
+		names = append(names, e.EventTypeId)
+	}
+	if len(f.IncludeTypes) > 0 && !containsAny(f.IncludeTypes, names) {
+		return false
+	}
+	if containsAny(f.ExcludeTypes, names) {
+		return false
+	}
+
+	if category != "" {
+		if len(f.IncludeCategories) > 0 && !contains(f.IncludeCategories, category) {
+			return false
+		}
+		if contains(f.ExcludeCategories, category) {
+			return false
+		}
+	}
+
+	userName := be.GetEvent().UserName
+	if len(f.IncludeUsers) > 0 && !contains(f.IncludeUsers, userName) {
+		return false
+	}
+	return !contains(f.ExcludeUsers, userName)
+}
+
+// isEventTypeId returns whether the type is the eventTypeId of an EventEx or ExtendedEvent, which are dotted
+// identifiers unlike the names of the event types
+func isEventTypeId(t string) bool {
+	return strings.Contains(t, ".")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(list []string, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
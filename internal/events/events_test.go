@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestEvents(t *testing.T) {
@@ -23,11 +24,12 @@ func TestEvents(t *testing.T) {
 		ref := simulator.Map.Any("VirtualMachine").Reference()
 
 		// https://pubs.vmware.com/vsphere-51/index.jsp?topic=%2Fcom.vmware.wssdk.apiref.doc%2Fvim.HistoryCollector.html
-		ed, err := NewEventDispacher(vc, ref, logrus.New(), &ca)
+		ed, err := NewEventDispacher(vc, ref, logrus.New(), &ca, Options{})
 		assert.NoError(t, err)
 
 		ed.CollectEvents("5")
 		assert.Equal(t, 6, len(ed.Events), "We were expecting 6 events")
+		assert.NotEmpty(t, ed.Severities[ed.Events[0].GetEvent().Key])
 		ed.Cancel()
 
 		ed.CollectEvents("noParsable")
@@ -36,8 +38,63 @@ func TestEvents(t *testing.T) {
 	}, model)
 }
 
+func TestEventsCheckpoint(t *testing.T) {
+	model := simulator.VPX()
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		ca := NewCacheMock{
+			TimestampCache: time.Now().Add(-15 * time.Second),
+		}
+		ref := simulator.Map.Any("VirtualMachine").Reference()
+
+		// given the events read up to a key, some of them sharing the second of the last one
+		ed, err := NewEventDispacher(vc, ref, logrus.New(), &ca, Options{})
+		assert.NoError(t, err)
+		ed.CollectEvents("100")
+		assert.Equal(t, 6, len(ed.Events))
+		ed.Cancel()
+		assert.NotNil(t, ca.KeyCache)
+		assert.Equal(t, ed.Events[5].GetEvent().Key, *ca.KeyCache)
+
+		// when collecting again from the same second
+		ed, err = NewEventDispacher(vc, ref, logrus.New(), &ca, Options{})
+		assert.NoError(t, err)
+		ed.CollectEvents("100")
+
+		// then the events already read are not returned again
+		assert.Equal(t, 0, len(ed.Events))
+		ed.Cancel()
+	}, model)
+}
+
+func TestEventsCatchUp(t *testing.T) {
+	model := simulator.VPX()
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		// given a last timestamp older than the max backfill
+		ca := NewCacheMock{
+			TimestampCache: time.Now().Add(-15 * time.Second),
+		}
+		ref := simulator.Map.Any("VirtualMachine").Reference()
+		options := Options{MaxBackfill: time.Second, CatchUp: true, MaxEvents: 4}
+
+		// when catching up, the backlog is read across several runs
+		ed, err := NewEventDispacher(vc, ref, logrus.New(), &ca, options)
+		assert.NoError(t, err)
+		ed.CollectEvents("3")
+		assert.Equal(t, 4, len(ed.Events))
+		ed.Cancel()
+
+		ed, err = NewEventDispacher(vc, ref, logrus.New(), &ca, options)
+		assert.NoError(t, err)
+		ed.CollectEvents("3")
+		assert.Equal(t, 2, len(ed.Events))
+		ed.Cancel()
+	}, model)
+}
+
 type NewCacheMock struct {
 	TimestampCache time.Time
+	KeyCache       *int32
+	ListCache      map[string][]string
 }
 
 func TestSanitizeTimestamp(t *testing.T) {
@@ -49,17 +106,26 @@ func TestSanitizeTimestamp(t *testing.T) {
 
 	err := fmt.Errorf("random Error")
 
-	s := sanitizeTimestamp(err, log, time.Time{}, now)
+	s := sanitizeTimestamp(err, log, time.Time{}, now, time.Hour, false)
 	assert.Equal(t, now, s)
 
-	s = sanitizeTimestamp(nil, log, lastTooNew, now)
+	s = sanitizeTimestamp(nil, log, lastTooNew, now, time.Hour, false)
 	assert.Equal(t, now, s)
 
-	s = sanitizeTimestamp(nil, log, lastTooOld, now)
+	s = sanitizeTimestamp(nil, log, lastTooOld, now, time.Hour, false)
 	assert.Equal(t, now.Add(time.Duration(-1)*time.Hour), s)
 
-	s = sanitizeTimestamp(nil, log, last, now)
-	assert.Equal(t, last.Add(time.Duration(1)*time.Second), s)
+	s = sanitizeTimestamp(nil, log, lastTooOld, now, 0, false)
+	assert.Equal(t, now.Add(time.Duration(-1)*time.Hour), s, "default max backfill")
+
+	s = sanitizeTimestamp(nil, log, lastTooOld, now, 48*time.Hour, false)
+	assert.Equal(t, lastTooOld, s)
+
+	s = sanitizeTimestamp(nil, log, lastTooOld, now, time.Hour, true)
+	assert.Equal(t, lastTooOld, s, "catching up")
+
+	s = sanitizeTimestamp(nil, log, last, now, time.Hour, false)
+	assert.Equal(t, last, s)
 }
 
 func (c *NewCacheMock) ReadTimestampCache() (time.Time, error) {
@@ -67,5 +133,145 @@ func (c *NewCacheMock) ReadTimestampCache() (time.Time, error) {
 }
 
 func (c *NewCacheMock) WriteTimestampCache(t time.Time) error {
+	c.TimestampCache = t
+	return nil
+}
+
+func (c *NewCacheMock) ReadKeyCache() (int32, error) {
+	if c.KeyCache == nil {
+		return 0, fmt.Errorf("key not found")
+	}
+	return *c.KeyCache, nil
+}
+
+func (c *NewCacheMock) WriteKeyCache(key int32) error {
+	c.KeyCache = &key
+	return nil
+}
+
+func (c *NewCacheMock) ReadListCache(name string) ([]string, error) {
+	values, ok := c.ListCache[name]
+	if !ok {
+		return nil, fmt.Errorf("list not found")
+	}
+	return values, nil
+}
+
+func (c *NewCacheMock) WriteListCache(name string, values []string) error {
+	if c.ListCache == nil {
+		c.ListCache = map[string][]string{}
+	}
+	c.ListCache[name] = values
 	return nil
 }
+
+func TestTasksCheckpoint(t *testing.T) {
+	last := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	completed := last.Add(5 * time.Minute)
+	ca := NewCacheMock{TimestampCache: last}
+
+	td := &TaskDispacher{
+		LastTimestamp:     &last,
+		lastKeys:          map[string]bool{"task-1": true},
+		lastReadTimestamp: last,
+		lastReadKeys:      []string{"task-1"},
+		inProgress:        map[string]string{"task-5": "queued", "task-6": "running"},
+		inProgressRead:    map[string]string{},
+		log:               logrus.New(),
+		c:                 &ca,
+	}
+
+	// given the task already read, another one completed in the same instant and two completed later on
+	td.addCompletedTasks([]types.TaskInfo{
+		{Key: "task-1", State: types.TaskInfoStateSuccess, CompleteTime: &last},
+		{Key: "task-2", State: types.TaskInfoStateError, CompleteTime: &last},
+		{Key: "task-3", State: types.TaskInfoStateSuccess, CompleteTime: &completed},
+		{Key: "task-4", State: types.TaskInfoStateSuccess, CompleteTime: &completed},
+	})
+	// and tasks in progress, one of them unchanged since the previous collection
+	td.addInProgressTasks([]types.TaskInfo{
+		{Key: "task-5", State: types.TaskInfoStateRunning},
+		{Key: "task-6", State: types.TaskInfoStateRunning},
+		{Key: "task-7", State: types.TaskInfoStateQueued},
+	})
+
+	// then only new tasks and changes of state are reported
+	var keys []string
+	for _, info := range td.Tasks {
+		keys = append(keys, info.Key)
+	}
+	assert.Equal(t, []string{"task-2", "task-3", "task-4", "task-5", "task-7"}, keys)
+	assert.Equal(t, completed, td.lastReadTimestamp)
+	assert.Equal(t, []string{"task-3", "task-4"}, td.lastReadKeys)
+
+	// and the state of the tasks in progress is kept for the next collection
+	td.saveCheckpoint()
+	assert.Equal(t, completed, ca.TimestampCache)
+	assert.Equal(t, []string{"task-3", "task-4"}, ca.ListCache[taskKeysCacheName])
+	assert.Equal(t, map[string]string{"task-5": "running", "task-6": "running", "task-7": "queued"}, readInProgress(&ca))
+}
+
+func TestEventFilterApply(t *testing.T) {
+	f := NewEventFilter("VmMigratedEvent", "UserLoginSessionEvent", "", "info", "admin", "")
+
+	spec := types.EventFilterSpec{}
+	f.apply(&spec)
+	assert.Equal(t, []string{"VmMigratedEvent"}, spec.Type)
+	assert.Empty(t, spec.EventTypeId)
+	assert.Equal(t, []string{"warning", "error", "user"}, spec.Category)
+	assert.Equal(t, []string{"admin"}, spec.UserName.UserList)
+
+	// EventEx ids only
+	spec = types.EventFilterSpec{}
+	NewEventFilter("com.vmware.vc.HA.HostFailedEvent", "", "", "", "", "").apply(&spec)
+	assert.Equal(t, []string{"EventEx", "ExtendedEvent"}, spec.Type)
+	assert.Equal(t, []string{"com.vmware.vc.HA.HostFailedEvent"}, spec.EventTypeId)
+	assert.Empty(t, spec.Category)
+	assert.Nil(t, spec.UserName)
+
+	// type names and EventEx ids together
+	spec = types.EventFilterSpec{}
+	NewEventFilter("VmMigratedEvent com.vmware.vc.HA.HostFailedEvent", "", "", "", "", "").apply(&spec)
+	assert.Equal(t, []string{"VmMigratedEvent", "EventEx", "ExtendedEvent"}, spec.Type)
+	assert.Empty(t, spec.EventTypeId)
+
+	// no filter
+	spec = types.EventFilterSpec{}
+	var none *EventFilter
+	none.apply(&spec)
+	assert.Empty(t, spec.Type)
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	login := &types.UserLoginSessionEvent{}
+	login.UserName = "admin"
+	migrated := &types.VmMigratedEvent{}
+	migrated.UserName = "admin"
+	haFailed := &types.EventEx{EventTypeId: "com.vmware.vc.HA.HostFailedEvent"}
+
+	f := NewEventFilter("", "UserLoginSessionEvent com.vmware.vc.HA.HostFailedEvent", "", "", "", "")
+	assert.False(t, f.matches(login, "info"))
+	assert.True(t, f.matches(migrated, "info"))
+	assert.False(t, f.matches(haFailed, "error"))
+
+	f = NewEventFilter("VmMigratedEvent EventEx", "", "", "", "", "")
+	assert.False(t, f.matches(login, "info"))
+	assert.True(t, f.matches(migrated, "info"))
+	assert.True(t, f.matches(haFailed, "error"))
+
+	f = NewEventFilter("", "", "warning Error", "", "", "")
+	assert.False(t, f.matches(migrated, "info"))
+	assert.True(t, f.matches(haFailed, "error"))
+	assert.True(t, f.matches(migrated, ""), "unknown categories are not filtered out")
+
+	f = NewEventFilter("", "", "", "", "admin", "")
+	assert.True(t, f.matches(migrated, "info"))
+	assert.False(t, f.matches(haFailed, "error"), "system events are left out when including users")
+
+	f = NewEventFilter("", "", "", "", "", "admin")
+	assert.False(t, f.matches(login, "info"))
+	assert.True(t, f.matches(haFailed, "error"))
+
+	var none *EventFilter
+	assert.True(t, none.matches(login, "info"))
+}
@@ -0,0 +1,260 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-vsphere/internal/cache"
+	logrus "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// names of the lists kept in the tasks cache along with the completion time of the last task read
+const (
+	taskKeysCacheName       = "keys"
+	taskInProgressCacheName = "inProgress"
+)
+
+// TaskDispacher collects the tasks of the TaskManager history. Tasks completed since the last ones collected are
+// returned only once, while the tasks still queued or running are returned each time their state changes.
+type TaskDispacher struct {
+	manager   *task.Manager
+	collector *task.HistoryCollector
+	ctx       *context.Context
+	entity    types.ManagedObjectReference
+
+	LastTimestamp *time.Time
+	// lastKeys holds the keys of the tasks completed at LastTimestamp that were already read, since the tasks
+	// completed in the same instant are fetched again
+	lastKeys map[string]bool
+	Tasks    []types.TaskInfo
+	options  Options
+	// lastRead holds the completion time of the last task read and the keys of the tasks completed at that time
+	lastReadTimestamp time.Time
+	lastReadKeys      []string
+	// inProgress holds the state of the tasks queued or running in the previous collection and in this one, by key
+	inProgress     map[string]string
+	inProgressRead map[string]string
+	log            *logrus.Logger
+	c              cache.CacheInterface
+}
+
+// NewTaskDispacher creates a collector for the tasks of the entity and its descendants completed since the last
+// ones collected. The backfill and catch up options of the events apply to tasks as well.
+func NewTaskDispacher(client *vim25.Client, mo types.ManagedObjectReference, log *logrus.Logger, c cache.CacheInterface, options Options) (*TaskDispacher, error) {
+
+	manager := task.NewManager(client)
+	ctx := context.Background()
+
+	now := time.Now()
+	lastTimestamp, err := c.ReadTimestampCache()
+	lastKeys, keysErr := c.ReadListCache(taskKeysCacheName)
+	if err == nil && keysErr != nil {
+		//the keys were not stored by previous versions, we are interested into the tasks completed since 1 second
+		//after the last one retrieved
+		lastTimestamp = lastTimestamp.Add(time.Duration(1) * time.Second)
+		lastKeys = nil
+	}
+	lastTimestamp = sanitizeTimestamp(err, log, lastTimestamp, now, options.MaxBackfill, options.CatchUp)
+
+	log.WithField("lastTimestamp", lastTimestamp.String()).WithField("lastKeys", lastKeys).Debug("Creating collector for tasks")
+	collector, err := manager.CreateCollectorForTasks(ctx,
+		types.TaskFilterSpec{
+			Time: &types.TaskFilterSpecByTime{
+				TimeType:  types.TaskFilterSpecTimeOptionCompletedTime,
+				BeginTime: &lastTimestamp,
+				EndTime:   &now,
+			},
+			Entity: &types.TaskFilterSpecByEntity{
+				Recursion: types.TaskFilterSpecRecursionOptionAll,
+				Entity:    mo,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating taskHistoryCollector: %s ", err.Error())
+	}
+
+	td := TaskDispacher{
+		manager:           manager,
+		collector:         collector,
+		ctx:               &ctx,
+		entity:            mo,
+		LastTimestamp:     &lastTimestamp,
+		lastKeys:          map[string]bool{},
+		Tasks:             []types.TaskInfo{},
+		options:           options,
+		lastReadTimestamp: lastTimestamp,
+		lastReadKeys:      lastKeys,
+		inProgress:        readInProgress(c),
+		inProgressRead:    map[string]string{},
+		log:               log,
+		c:                 c,
+	}
+	for _, key := range lastKeys {
+		td.lastKeys[key] = true
+	}
+	return &td, nil
+}
+
+// readInProgress returns the state of the tasks in progress stored as key=state, empty if not stored
+func readInProgress(c cache.CacheInterface) map[string]string {
+	inProgress := map[string]string{}
+	values, err := c.ReadListCache(taskInProgressCacheName)
+	if err != nil {
+		return inProgress
+	}
+	for _, v := range values {
+		if i := strings.LastIndex(v, "="); i > 0 {
+			inProgress[v[:i]] = v[i+1:]
+		}
+	}
+	return inProgress
+}
+
+// Cancel destroys the collector and saves the checkpoint of the tasks read
+func (td *TaskDispacher) Cancel() {
+	err := td.collector.Destroy(*td.ctx)
+	if err != nil {
+		td.log.WithError(err).Error("error while destroying task collector")
+	}
+	td.saveCheckpoint()
+}
+
+// saveCheckpoint stores the completion time and keys of the last tasks read, along with the state of the tasks in
+// progress, so the next collection starts right after them
+func (td *TaskDispacher) saveCheckpoint() {
+	t := td.lastReadTimestamp
+
+	td.log.WithField("date", t).WithField("keys", td.lastReadKeys).Debug("saving in cache last completed task")
+	err := td.c.WriteTimestampCache(t)
+	if err != nil {
+		td.log.WithError(err).Error("error while saving cache")
+	}
+	err = td.c.WriteListCache(taskKeysCacheName, td.lastReadKeys)
+	if err != nil {
+		td.log.WithError(err).Error("error while saving cache")
+	}
+
+	inProgress := make([]string, 0, len(td.inProgressRead))
+	for key, state := range td.inProgressRead {
+		inProgress = append(inProgress, key+"="+state)
+	}
+	sort.Strings(inProgress)
+	err = td.c.WriteListCache(taskInProgressCacheName, inProgress)
+	if err != nil {
+		td.log.WithError(err).Error("error while saving cache")
+	}
+	td.LastTimestamp = &t
+}
+
+func (td *TaskDispacher) CollectTasks(tasksPageSize string) {
+	td.log.WithField("timestamp", td.LastTimestamp.String()).Debug("using as starting task")
+
+	parsedPageSize, err := strconv.ParseInt(tasksPageSize, 10, 32)
+	pageSize := int(parsedPageSize)
+	if err != nil {
+		td.log.WithError(err).Error("error while parsing TasksPageSize, using default value")
+		pageSize = pageSizeDefault
+	}
+
+	// when catching up no more than MaxEvents tasks are read, the rest are left for the following runs
+	maxTasks := 0
+	if td.options.CatchUp {
+		maxTasks = td.options.MaxEvents
+	}
+	td.addCompletedTasks(td.readTasks(td.collector, pageSize, maxTasks))
+
+	// tasks still in progress have no completion time, so they are fetched by state with a collector of their own
+	inProgress, err := td.manager.CreateCollectorForTasks(*td.ctx,
+		types.TaskFilterSpec{
+			State: []types.TaskInfoState{types.TaskInfoStateQueued, types.TaskInfoStateRunning},
+			Entity: &types.TaskFilterSpecByEntity{
+				Recursion: types.TaskFilterSpecRecursionOptionAll,
+				Entity:    td.entity,
+			},
+		},
+	)
+	if err != nil {
+		td.log.WithError(err).Error("error while creating collector for tasks in progress")
+		// the state of the tasks in progress is kept for the next collection
+		td.inProgressRead = td.inProgress
+		return
+	}
+	defer func() {
+		err := inProgress.Destroy(*td.ctx)
+		if err != nil {
+			td.log.WithError(err).Error("error while destroying task collector")
+		}
+	}()
+
+	td.addInProgressTasks(td.readTasks(inProgress, pageSize, 0))
+}
+
+// addCompletedTasks appends the tasks not read yet, dropping those completed at the last completion time that were
+// already read in the previous collection, and keeps track of the last ones
+func (td *TaskDispacher) addCompletedTasks(tasks []types.TaskInfo) {
+	for _, info := range tasks {
+		if info.CompleteTime == nil || td.lastKeys[info.Key] {
+			continue
+		}
+		td.Tasks = append(td.Tasks, info)
+
+		switch {
+		case info.CompleteTime.After(td.lastReadTimestamp):
+			td.lastReadTimestamp = *info.CompleteTime
+			td.lastReadKeys = []string{info.Key}
+		case info.CompleteTime.Equal(td.lastReadTimestamp):
+			td.lastReadKeys = append(td.lastReadKeys, info.Key)
+		}
+	}
+}
+
+// addInProgressTasks appends the tasks queued or running whose state changed since the previous collection
+func (td *TaskDispacher) addInProgressTasks(tasks []types.TaskInfo) {
+	for _, info := range tasks {
+		state := string(info.State)
+		td.inProgressRead[info.Key] = state
+		if td.inProgress[info.Key] == state {
+			continue
+		}
+		td.Tasks = append(td.Tasks, info)
+	}
+}
+
+// readTasks reads the tasks of the collector, up to maxTasks if greater than 0
+func (td *TaskDispacher) readTasks(collector *task.HistoryCollector, pageSize int, maxTasks int) []types.TaskInfo {
+	var tasks []types.TaskInfo
+	for {
+		size := pageSize
+		if maxTasks > 0 {
+			if len(tasks) >= maxTasks {
+				td.log.WithField("number", len(tasks)).Info("max tasks read while catching up, the rest are fetched in the next run")
+				break
+			}
+			if left := maxTasks - len(tasks); left < size {
+				size = left
+			}
+		}
+
+		tasksCollected, err := collector.ReadNextTasks(*td.ctx, int32(size))
+		if err != nil {
+			td.log.WithError(err).Error("error while fetching tasks")
+			break
+		}
+		tasks = append(tasks, tasksCollected...)
+		td.log.WithField("number", len(tasksCollected)).Debug("readNextTasksExecuted")
+
+		//There are no tasks left if: no tasks has been collected or if the number of tasks is smaller than the pagSize
+		if len(tasksCollected) == 0 || len(tasksCollected) != size {
+			break
+		}
+	}
+	return tasks
+}
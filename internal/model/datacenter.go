@@ -10,6 +10,7 @@ import (
 	"github.com/newrelic/nri-vsphere/internal/performance"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
 )
 
 type mor = types.ManagedObjectReference
@@ -18,6 +19,7 @@ type mor = types.ManagedObjectReference
 type Datacenter struct {
 	Datacenter      *mo.Datacenter
 	EventDispacher  *events.EventDispacher
+	TaskDispacher   *events.TaskDispacher
 	Hosts           map[mor]*mo.HostSystem
 	Clusters        map[mor]*mo.ClusterComputeResource
 	ResourcePools   map[mor]*mo.ResourcePool
@@ -26,6 +28,59 @@ type Datacenter struct {
 	VirtualMachines map[mor]*mo.VirtualMachine
 	PerfMetrics     map[mor][]performance.PerfMetric
 	PerfMetricsMux  sync.Mutex
+
+	StoragePods map[mor]*mo.StoragePod
+
+	DistributedSwitches   map[mor]*mo.DistributedVirtualSwitch
+	DistributedPortgroups map[mor]*mo.DistributedVirtualPortgroup
+	// DistributedPortCounts holds the port counts of both distributed switches and portgroups
+	DistributedPortCounts map[mor]*PortCounts
+
+	// VirtualDiskStoragePolicies holds the storage policy name of the virtual disks by vm and disk key
+	VirtualDiskStoragePolicies map[mor]map[int32]string
+
+	// VsanClusters holds the vSAN data of the vSAN enabled clusters
+	VsanClusters map[mor]*VsanCluster
+
+	// TriggeredAlarms holds the alarms currently triggered by entity
+	TriggeredAlarms map[mor][]TriggeredAlarm
+}
+
+// PortCounts struct
+type PortCounts struct {
+	Total   int
+	Used    int
+	Blocked int
+}
+
+// TriggeredAlarm is the state of an alarm triggered on an entity along with the alarm name
+type TriggeredAlarm struct {
+	types.AlarmState
+	Name string
+}
+
+// VsanCluster holds the data reported by the vSAN management API for a vSAN enabled cluster
+type VsanCluster struct {
+	SpaceUsage *vsantypes.VsanSpaceUsage
+	Health     *vsantypes.VsanClusterHealthSummary
+	Resync     *vsantypes.VsanHostVsanObjectSyncQueryResult
+	// PerfMetrics holds the latest value of the vSAN performance service counters of the cluster
+	PerfMetrics map[string]float64
+	// HostPerfMetrics holds the latest value of the vSAN performance service counters of each host
+	HostPerfMetrics map[mor]map[string]float64
+}
+
+// FindPhysicalDisksHealth returns the health of the vSAN physical disks of the host with the given name
+func (vc *VsanCluster) FindPhysicalDisksHealth(hostName string) *vsantypes.VsanPhysicalDiskHealthSummary {
+	if vc.Health == nil {
+		return nil
+	}
+	for i, summary := range vc.Health.PhysicalDisksHealth {
+		if summary.Hostname == hostName {
+			return &vc.Health.PhysicalDisksHealth[i]
+		}
+	}
+	return nil
 }
 
 // NewDatacenter Initialize datacenter struct
@@ -39,6 +94,18 @@ func NewDatacenter(datacenter *mo.Datacenter) *Datacenter {
 		Networks:        make(map[mor]*mo.Network),
 		VirtualMachines: make(map[mor]*mo.VirtualMachine),
 		PerfMetrics:     make(map[mor][]performance.PerfMetric),
+
+		StoragePods: make(map[mor]*mo.StoragePod),
+
+		DistributedSwitches:   make(map[mor]*mo.DistributedVirtualSwitch),
+		DistributedPortgroups: make(map[mor]*mo.DistributedVirtualPortgroup),
+		DistributedPortCounts: make(map[mor]*PortCounts),
+
+		VirtualDiskStoragePolicies: make(map[mor]map[int32]string),
+
+		VsanClusters: make(map[mor]*VsanCluster),
+
+		TriggeredAlarms: make(map[mor][]TriggeredAlarm),
 	}
 }
 
@@ -65,6 +132,44 @@ func (dc *Datacenter) FindHost(computeResourceReference mor) *mo.HostSystem {
 	return nil
 }
 
+// FindStoragePod returns the datastore cluster the datastore is member of, if any
+func (dc *Datacenter) FindStoragePod(datastoreReference mor) *mo.StoragePod {
+	for _, pod := range dc.StoragePods {
+		for _, child := range pod.ChildEntity {
+			if child == datastoreReference {
+				return pod
+			}
+		}
+	}
+	return nil
+}
+
+// FindDistributedSwitches returns the distributed switches the host is member of
+func (dc *Datacenter) FindDistributedSwitches(hostReference mor) (dvs []*mo.DistributedVirtualSwitch) {
+	for _, sw := range dc.DistributedSwitches {
+		for _, member := range sw.Summary.HostMember {
+			if member == hostReference {
+				dvs = append(dvs, sw)
+				break
+			}
+		}
+	}
+	return
+}
+
+// FindDistributedPortgroup returns the distributed portgroup with the given key of the switch with the given uuid
+func (dc *Datacenter) FindDistributedPortgroup(switchUuid string, portgroupKey string) *mo.DistributedVirtualPortgroup {
+	for _, pg := range dc.DistributedPortgroups {
+		if pg.Config.Key != portgroupKey || pg.Config.DistributedVirtualSwitch == nil {
+			continue
+		}
+		if sw, ok := dc.DistributedSwitches[*pg.Config.DistributedVirtualSwitch]; ok && sw.Uuid == switchUuid {
+			return pg
+		}
+	}
+	return nil
+}
+
 // GetResourcePool returns the name of the Resource Pool if is not the default
 func (dc *Datacenter) GetResourcePool(resourcePoolReference mor) (*mo.ResourcePool, bool) {
 	if !dc.IsDefaultResourcePool(resourcePoolReference) {
@@ -93,6 +198,13 @@ func (dc *Datacenter) AddPerfMetrics(data map[types.ManagedObjectReference][]per
 	}
 }
 
+// ClearPerfMetrics removes the perf metrics previously added to the dc
+func (dc *Datacenter) ClearPerfMetrics() {
+	dc.PerfMetricsMux.Lock()
+	defer dc.PerfMetricsMux.Unlock()
+	dc.PerfMetrics = make(map[mor][]performance.PerfMetric)
+}
+
 // GetPerfMetrics returns the slice of Perf metrics for the given object reference
 func (dc *Datacenter) GetPerfMetrics(ref mor) []performance.PerfMetric {
 	if perfMetrics, ok := dc.PerfMetrics[ref]; ok {
@@ -24,6 +24,12 @@ const (
 
 	RealTimeInterval    = 20
 	FiveMinutesInterval = 300
+
+	// Aggregations of the values of the different instances of a counter
+	AggregationAvg = "avg"
+	AggregationSum = "sum"
+	AggregationMax = "max"
+	AggregationMin = "min"
 )
 
 type PerfCollector struct {
@@ -37,12 +43,16 @@ type PerfCollector struct {
 	metricsAvaliableByName map[string]int32
 	batchSizePerfEntities  int
 	batchSizePerfMetrics   int
+	// aggregations holds how the values of the instances are aggregated by counter, the average if not present
+	aggregations map[string]string
 }
 
 //this struct is not needed we can decide to pass more info and process it in the process, it would hide logic
 type PerfMetric struct {
 	Value   int64
 	Counter string
+	// Instances holds the value reported for each instance of the counter, such as a virtual disk or a nic
+	Instances map[string]int64
 }
 
 func NewCollector(client *govmomi.Client, logger *logrus.Logger, perfMetricFile string, logAvailableCounters bool, collectionLevel int, batchSizePerfEntitiesString string, batchSizePerfMetricsString string) (*PerfCollector, error) {
@@ -131,22 +141,51 @@ func (c *PerfCollector) Collect(mos []types.ManagedObjectReference, metrics []ty
 // 		-  For memory and aggregated statistics, this property is empty.
 // 		-  For host and virtual machine devices, this property contains the name of the device, such as the name of the host-bus   adapter or the name of the virtual Ethernet adapter. For example, “mpx.vmhba33:C0:T0:L0” or “vmnic0:”
 // 		-  For a CPU, this property identifies the numeric position within the CPU core, such as 0, 1, 2, 3."""
-// We give priority to the values having the `instance` specified. If more than one value is returned we compute the average.
+// We give priority to the values having the `instance` specified. If more than one value is returned we aggregate them,
+// computing the average unless a different aggregation is configured for the counter.
 // If no value having an 'instance' is found for a perf metric we fall back to 'instanceless' values.
 // If no value is returned we do not report that specific perf metric
 type perfEvaluer struct {
 	instancelessValue *int64
 	accumulator       accumulator
+	instances         map[string]int64
 }
 
 type accumulator struct {
 	Occurrences int64
 	Sum         int64
+	Max         int64
+	Min         int64
+}
+
+func (a *accumulator) add(value int64) {
+	if a.Occurrences == 0 || value > a.Max {
+		a.Max = value
+	}
+	if a.Occurrences == 0 || value < a.Min {
+		a.Min = value
+	}
+	a.Occurrences++
+	a.Sum += value
+}
+
+// aggregate returns the aggregation of the values accumulated, which must be at least one
+func (a *accumulator) aggregate(aggregation string) int64 {
+	switch aggregation {
+	case AggregationSum:
+		return a.Sum
+	case AggregationMax:
+		return a.Max
+	case AggregationMin:
+		return a.Min
+	default:
+		return a.Sum / a.Occurrences
+	}
 }
 
 func (c *PerfCollector) processEntityMetrics(metricsValues *types.PerfEntityMetric, perfMetricsByRef map[types.ManagedObjectReference][]PerfMetric) {
 
-	// If for the same metrics multiple instances are returned we perform the average of the values
+	// If for the same metrics multiple instances are returned we aggregate the values
 	accumulateMetrics := map[string]*perfEvaluer{}
 
 	if metricsValues == nil {
@@ -169,21 +208,22 @@ func (c *PerfCollector) processEntityMetrics(metricsValues *types.PerfEntityMetr
 
 		//We give priority to the raw values and fall back to 'instanceless' values in case no raw data has been received
 		if val.accumulator.Occurrences != 0 {
-			value = val.accumulator.Sum / val.accumulator.Occurrences
+			value = val.accumulator.aggregate(c.aggregations[key])
 		} else if val.instancelessValue != nil {
 			value = *val.instancelessValue
 		}
 
 		perfMetricsByRef[metricsValues.Entity] = append(perfMetricsByRef[metricsValues.Entity], PerfMetric{
-			Counter: key,
-			Value:   value,
+			Counter:   key,
+			Value:     value,
+			Instances: val.instances,
 		})
 	}
 
 }
 
 func accumulateValues(accumulateMetrics map[string]*perfEvaluer, metricName string, metricValue types.BasePerfMetricSeries, metricVal int64) {
-	// This is a short-lived object, the purpose is to compute the average of the different performance metrics
+	// This is a short-lived object, the purpose is to aggregate the different performance metrics
 	// when more than one instance per entity returns a value
 	pe, ok := accumulateMetrics[metricName]
 	if !ok {
@@ -191,9 +231,12 @@ func accumulateValues(accumulateMetrics map[string]*perfEvaluer, metricName stri
 		accumulateMetrics[metricName] = pe
 	}
 
-	if metricValue.GetPerfMetricSeries().Id.Instance != "" {
-		pe.accumulator.Occurrences++
-		pe.accumulator.Sum += metricVal
+	if instance := metricValue.GetPerfMetricSeries().Id.Instance; instance != "" {
+		pe.accumulator.add(metricVal)
+		if pe.instances == nil {
+			pe.instances = map[string]int64{}
+		}
+		pe.instances[instance] = metricVal
 	} else {
 		pe.instancelessValue = &metricVal
 	}
@@ -271,10 +314,25 @@ func (c *PerfCollector) parseConfigFile(fileName string) error {
 		Datastore:              c.buildPerMetricID(cf.Datastore),
 		Host:                   c.buildPerMetricID(cf.Host),
 	}
+	c.aggregations = c.buildAggregations(cf.Aggregation)
 
 	return nil
 }
 
+// buildAggregations returns the aggregations configured by counter, dropping the ones not supported
+func (c *PerfCollector) buildAggregations(aggregationByCounter map[string]string) map[string]string {
+	aggregations := map[string]string{}
+	for counter, aggregation := range aggregationByCounter {
+		switch aggregation {
+		case AggregationAvg, AggregationSum, AggregationMax, AggregationMin:
+			aggregations[counter] = aggregation
+		default:
+			c.logger.WithField("counter", counter).WithField("aggregation", aggregation).Warn("aggregation not supported, using avg")
+		}
+	}
+	return aggregations
+}
+
 func (c *PerfCollector) buildPerMetricID(countersByLevel map[string][]string) []types.PerfMetricId {
 	var tmp []types.PerfMetricId
 	maxLevel := fmt.Sprintf("level_%d", c.collectionLevel)
@@ -314,6 +372,7 @@ type ymlConfig struct {
 	ResourcePool           map[string][]string `yaml:"resourcePool"`
 	ClusterComputeResource map[string][]string `yaml:"clusterComputeResource"`
 	Datastore              map[string][]string `yaml:"datastore"`
+	Aggregation            map[string]string   `yaml:"aggregation"`
 }
 
 func min(a, b int) int {
@@ -42,6 +42,9 @@ vm:
     - cpu.demand.average
   level_3:
     - cpu.outoflevel
+aggregation:
+  cpu.coreUtilization.average: max
+  cpu.demand.average: median
 `)
 
 	tmpfile, err := ioutil.TempFile("", "config")
@@ -61,6 +64,9 @@ vm:
 	assert.Len(t, c.MetricDefinition.Host, 2)
 
 	assert.Len(t, c.MetricDefinition.VM, 1)
+
+	// - median is not a supported aggregation
+	assert.Equal(t, map[string]string{"cpu.coreUtilization.average": AggregationMax}, c.aggregations)
 }
 
 func TestPerfCollector_NewCollector(t *testing.T) {
@@ -297,6 +303,10 @@ func TestPerfEvaluer(t *testing.T) {
 		}
 		if val.Counter == "mixed" {
 			assert.Equal(t, int64(150), val.Value)
+			assert.Equal(t, map[string]int64{"Instance1": 75, "Instance2": 225}, val.Instances)
+		}
+		if val.Counter == "noInstance" {
+			assert.Nil(t, val.Instances)
 		}
 		if val.Counter == "Unavailable" {
 			assert.Fail(t, "Unavailable counter should not have been populated")
@@ -304,6 +314,48 @@ func TestPerfEvaluer(t *testing.T) {
 	}
 }
 
+func TestPerfAggregation(t *testing.T) {
+	p := PerfCollector{
+		logger:                 logrus.New(),
+		metricsAvaliableByID:   map[int32]string{1: "avg", 2: "sum", 3: "max", 4: "min", 5: "mixed"},
+		metricsAvaliableByName: map[string]int32{"avg": 1, "sum": 2, "max": 3, "min": 4, "mixed": 5},
+	}
+	p.aggregations = p.buildAggregations(map[string]string{
+		"sum":   AggregationSum,
+		"max":   AggregationMax,
+		"min":   AggregationMin,
+		"mixed": AggregationMax,
+		"avg":   "unknown",
+	})
+	assert.Equal(t, map[string]string{"sum": "sum", "max": "max", "min": "min", "mixed": "max"}, p.aggregations)
+
+	perfMetricsByRef := map[types.ManagedObjectReference][]PerfMetric{}
+	hostEntity := types.ManagedObjectReference{Type: "Host", Value: "Host-155"}
+
+	var series []types.BasePerfMetricSeries
+	for counter := int32(1); counter <= 4; counter++ {
+		series = append(series,
+			returnPerfMetricIntSeries(counter, "Instance1", 75),
+			returnPerfMetricIntSeries(counter, "Instance2", 225),
+			returnPerfMetricIntSeries(counter, "Instance3", 0))
+	}
+	// the instanceless value is ignored when instances are reported
+	series = append(series,
+		returnPerfMetricIntSeries(5, "", 900),
+		returnPerfMetricIntSeries(5, "Instance1", 75))
+
+	p.processEntityMetrics(&types.PerfEntityMetric{
+		PerfEntityMetricBase: types.PerfEntityMetricBase{Entity: hostEntity},
+		Value:                series,
+	}, perfMetricsByRef)
+
+	values := map[string]int64{}
+	for _, val := range perfMetricsByRef[hostEntity] {
+		values[val.Counter] = val.Value
+	}
+	assert.Equal(t, map[string]int64{"avg": 100, "sum": 300, "max": 225, "min": 0, "mixed": 75}, values)
+}
+
 func returnPerfMetricIntSeries(counter int32, instanceName string, value int64) *types.PerfMetricIntSeries {
 	return &types.PerfMetricIntSeries{
 		PerfMetricSeries: types.PerfMetricSeries{
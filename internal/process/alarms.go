@@ -0,0 +1,41 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// createTriggeredAlarmSamples adds a sample for each alarm currently triggered on the object to its entity
+func createTriggeredAlarmSamples(config *config.Config, e *integration.Entity, dc *model.Datacenter, ref types.ManagedObjectReference) {
+	for _, alarm := range dc.TriggeredAlarms[ref] {
+		ms := e.NewMetricSet("VSphere" + sampleTypeTriggeredAlarm + "Sample")
+
+		checkError(config.Logrus, ms.SetMetric("alarmName", alarm.Name, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("alarmId", alarm.Alarm.Value, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("key", alarm.Key, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("status", string(alarm.OverallStatus), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("triggeredTime", alarm.Time.Format(time.RFC1123), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("entityType", ref.Type, metric.ATTRIBUTE))
+
+		acknowledged := alarm.Acknowledged != nil && *alarm.Acknowledged
+		checkError(config.Logrus, ms.SetMetric("acknowledged", fmt.Sprintf("%t", acknowledged), metric.ATTRIBUTE))
+		if acknowledged {
+			checkError(config.Logrus, ms.SetMetric("acknowledgedByUser", alarm.AcknowledgedByUser, metric.ATTRIBUTE))
+			if alarm.AcknowledgedTime != nil {
+				checkError(config.Logrus, ms.SetMetric("acknowledgedTime", alarm.AcknowledgedTime.Format(time.RFC1123), metric.ATTRIBUTE))
+			}
+		}
+		if alarm.EventKey != 0 {
+			checkError(config.Logrus, ms.SetMetric("eventKey", fmt.Sprintf("%d", alarm.EventKey), metric.ATTRIBUTE))
+		}
+	}
+}
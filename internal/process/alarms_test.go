@@ -0,0 +1,65 @@
+package process
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createTriggeredAlarmSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given an alarm triggered on a host and acknowledged
+		finder := find.NewFinder(vc)
+		dc, err := finder.DefaultDatacenter(ctx)
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+		host, err := finder.HostSystem(ctx, "DC0_H0")
+		require.NoError(t, err)
+
+		alarm := &mo.Alarm{
+			ExtensibleManagedObject: mo.ExtensibleManagedObject{Self: types.ManagedObjectReference{Type: "Alarm", Value: "alarm-1"}},
+			Info:                    types.AlarmInfo{AlarmSpec: types.AlarmSpec{Name: "Host connection failure"}},
+		}
+		simulator.Map.Put(alarm)
+		acknowledged := true
+		triggered := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+		simulator.Map.Get(dc.Reference()).(*simulator.Datacenter).TriggeredAlarmState = []types.AlarmState{{
+			Key:                "alarm-1.host-1",
+			Entity:             host.Reference(),
+			Alarm:              alarm.Self,
+			OverallStatus:      types.ManagedEntityStatusRed,
+			Time:               triggered,
+			Acknowledged:       &acknowledged,
+			AcknowledgedByUser: "admin",
+		}}
+
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.Alarms(cfg)
+
+		createHostSamples(cfg)
+
+		// then
+		alarms := samplesByEventType(cfg.Integration.Entities...)["VSphereTriggeredAlarmSample"]
+		require.Len(t, alarms, 1)
+		assert.Equal(t, "Host connection failure", alarms[0]["alarmName"])
+		assert.Equal(t, "red", alarms[0]["status"])
+		assert.Equal(t, "HostSystem", alarms[0]["entityType"])
+		assert.Equal(t, "true", alarms[0]["acknowledged"])
+		assert.Equal(t, "admin", alarms[0]["acknowledgedByUser"])
+		// times use the same format as events
+		assert.Equal(t, "Wed, 01 May 2024 10:30:00 UTC", alarms[0]["triggeredTime"])
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	auditEventCategory     = "vSphereAuditEvent"
+	auditAttributePrefix   = "vSphereAuditEvent."
+	auditOutcomeSuccess    = "success"
+	auditOutcomeFailure    = "failure"
+	auditTargetTypeUser    = "User"
+	auditTargetTypeRole    = "Role"
+	auditTargetTypeAccount = "HostAccount"
+)
+
+// Actions of the EventEx events reported by the vCenter single sign-on and by ESXi hosts
+var auditEventTypeIds = map[string]struct {
+	action  string
+	outcome string
+}{
+	"com.vmware.sso.LoginSuccess":     {"login", auditOutcomeSuccess},
+	"com.vmware.sso.LoginFailure":     {"loginFailed", auditOutcomeFailure},
+	"com.vmware.sso.Logout":           {"logout", auditOutcomeSuccess},
+	"esx.audit.account.loginfailures": {"loginFailed", auditOutcomeFailure},
+	"esx.audit.account.locked":        {"accountLocked", auditOutcomeFailure},
+}
+
+// auditEvent holds the normalized fields of a security relevant event
+type auditEvent struct {
+	action     string
+	outcome    string
+	actor      string
+	sourceIp   string
+	userAgent  string
+	session    string
+	targetType string
+	targetName string
+	attributes map[string]interface{}
+}
+
+// newAuditEvent returns the normalized fields of login, session, permission, role and account events, or nil
+// for any other event
+func newAuditEvent(be types.BaseEvent) *auditEvent {
+	a := &auditEvent{
+		outcome:    auditOutcomeSuccess,
+		actor:      be.GetEvent().UserName,
+		attributes: map[string]interface{}{},
+	}
+
+	switch e := be.(type) {
+	// sessions
+	case *types.UserLoginSessionEvent:
+		a.action = "login"
+		a.sourceIp, a.userAgent, a.session = e.IpAddress, e.UserAgent, sessionHash(e.SessionId)
+	case *types.UserLogoutSessionEvent:
+		a.action = "logout"
+		a.sourceIp, a.userAgent, a.session = e.IpAddress, e.UserAgent, sessionHash(e.SessionId)
+	case *types.BadUsernameSessionEvent:
+		a.action, a.outcome = "loginFailed", auditOutcomeFailure
+		a.sourceIp = e.IpAddress
+	case *types.NoAccessUserEvent:
+		a.action, a.outcome = "accessDenied", auditOutcomeFailure
+		a.sourceIp = e.IpAddress
+	case *types.SessionTerminatedEvent:
+		a.action = "sessionTerminated"
+		a.session = sessionHash(e.SessionId)
+		a.targetType, a.targetName = auditTargetTypeUser, e.TerminatedUsername
+
+	// permissions
+	case *types.PermissionAddedEvent:
+		a.action = "permissionAdded"
+		a.setPermission(e.PermissionEvent)
+		a.attributes["role"] = e.Role.Name
+		a.attributes["propagate"] = fmt.Sprintf("%t", e.Propagate)
+	case *types.PermissionUpdatedEvent:
+		a.action = "permissionUpdated"
+		a.setPermission(e.PermissionEvent)
+		a.attributes["role"] = e.Role.Name
+		a.attributes["propagate"] = fmt.Sprintf("%t", e.Propagate)
+		if e.PrevRole != nil {
+			a.attributes["previousRole"] = e.PrevRole.Name
+		}
+	case *types.PermissionRemovedEvent:
+		a.action = "permissionRemoved"
+		a.setPermission(e.PermissionEvent)
+
+	// roles
+	case *types.RoleAddedEvent:
+		a.action = "roleAdded"
+		a.targetType, a.targetName = auditTargetTypeRole, e.Role.Name
+		a.setList("privilegesAdded", e.PrivilegeList)
+	case *types.RoleUpdatedEvent:
+		a.action = "roleUpdated"
+		a.targetType, a.targetName = auditTargetTypeRole, e.Role.Name
+		if e.PrevRoleName != "" && e.PrevRoleName != e.Role.Name {
+			a.attributes["previousRole"] = e.PrevRoleName
+		}
+		a.setList("privilegesAdded", e.PrivilegesAdded)
+		a.setList("privilegesRemoved", e.PrivilegesRemoved)
+	case *types.RoleRemovedEvent:
+		a.action = "roleRemoved"
+		a.targetType, a.targetName = auditTargetTypeRole, e.Role.Name
+
+	// host accounts
+	case *types.AccountCreatedEvent:
+		a.action = "accountCreated"
+		a.setAccount(e.Spec, e.Group)
+	case *types.AccountUpdatedEvent:
+		a.action = "accountUpdated"
+		a.setAccount(e.Spec, e.Group)
+	case *types.AccountRemovedEvent:
+		a.action = "accountRemoved"
+		a.targetType, a.targetName = auditTargetTypeAccount, e.Account
+		a.attributes["isGroup"] = fmt.Sprintf("%t", e.Group)
+	case *types.UserPasswordChanged:
+		a.action = "passwordChanged"
+		a.targetType, a.targetName = auditTargetTypeAccount, e.UserLogin
+
+	// single sign-on and ESXi events
+	case *types.EventEx:
+		eventTypeId, ok := auditEventTypeIds[e.EventTypeId]
+		if !ok {
+			return nil
+		}
+		a.action, a.outcome = eventTypeId.action, eventTypeId.outcome
+		if a.actor == "" {
+			a.actor = e.ObjectName
+		}
+
+	default:
+		return nil
+	}
+	return a
+}
+
+// sessionHash returns a hash of the session id, which identifies the session across its events without exposing the
+// session key, that could be used to take over the session while still active
+func sessionHash(sessionId string) string {
+	if sessionId == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sessionId))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *auditEvent) setPermission(e types.PermissionEvent) {
+	a.targetType, a.targetName = e.Entity.Entity.Type, e.Entity.Name
+	a.attributes["principal"] = e.Principal
+	a.attributes["isGroup"] = fmt.Sprintf("%t", e.Group)
+}
+
+func (a *auditEvent) setAccount(spec types.BaseHostAccountSpec, group bool) {
+	a.targetType = auditTargetTypeAccount
+	if spec != nil {
+		a.targetName = spec.GetHostAccountSpec().Id
+	}
+	a.attributes["isGroup"] = fmt.Sprintf("%t", group)
+}
+
+func (a *auditEvent) setList(name string, values []string) {
+	if len(values) > 0 {
+		a.attributes[name] = strings.Join(values, "|")
+	}
+}
+
+// setAttributes adds the normalized fields to the attributes of the event, leaving out the empty ones
+func (a *auditEvent) setAttributes(attributes map[string]interface{}) {
+	fields := map[string]string{
+		"action":      a.action,
+		"outcome":     a.outcome,
+		"actor":       a.actor,
+		"sourceIp":    a.sourceIp,
+		"userAgent":   a.userAgent,
+		"sessionHash": a.session,
+		"targetType":  a.targetType,
+		"targetName":  a.targetName,
+	}
+	for k, v := range fields {
+		if v != "" {
+			attributes[auditAttributePrefix+k] = v
+		}
+	}
+	for k, v := range a.attributes {
+		attributes[auditAttributePrefix+k] = v
+	}
+}
@@ -0,0 +1,121 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/events"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newAuditEvent(t *testing.T) {
+	// given a login
+	login := &types.UserLoginSessionEvent{IpAddress: "10.0.0.1", UserAgent: "govc", SessionId: "52a1"}
+	login.UserName = "VSPHERE.LOCAL\\admin"
+
+	attributes := map[string]interface{}{}
+	newAuditEvent(login).setAttributes(attributes)
+
+	assert.Equal(t, "login", attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "success", attributes["vSphereAuditEvent.outcome"])
+	assert.Equal(t, "VSPHERE.LOCAL\\admin", attributes["vSphereAuditEvent.actor"])
+	assert.Equal(t, "10.0.0.1", attributes["vSphereAuditEvent.sourceIp"])
+	assert.Equal(t, "govc", attributes["vSphereAuditEvent.userAgent"])
+	// the session key is never reported, only a hash to correlate the events of the session
+	assert.NotContains(t, attributes, "vSphereAuditEvent.sessionId")
+	assert.Equal(t, sessionHash("52a1"), attributes["vSphereAuditEvent.sessionHash"])
+	assert.Len(t, attributes["vSphereAuditEvent.sessionHash"], 64)
+	for _, v := range attributes {
+		assert.NotEqual(t, "52a1", v)
+	}
+	assert.NotContains(t, attributes, "vSphereAuditEvent.targetName")
+
+	// given a failed login
+	failed := &types.BadUsernameSessionEvent{IpAddress: "10.0.0.2"}
+	failed.UserName = "root"
+
+	attributes = map[string]interface{}{}
+	newAuditEvent(failed).setAttributes(attributes)
+
+	assert.Equal(t, "loginFailed", attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "failure", attributes["vSphereAuditEvent.outcome"])
+	assert.Equal(t, "root", attributes["vSphereAuditEvent.actor"])
+
+	// given a permission added on a vm
+	permission := &types.PermissionAddedEvent{
+		PermissionEvent: types.PermissionEvent{
+			Entity: types.ManagedEntityEventArgument{
+				EntityEventArgument: types.EntityEventArgument{Name: "vm-1"},
+				Entity:              types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			},
+			Principal: "VSPHERE.LOCAL\\operators",
+			Group:     true,
+		},
+		Role: types.RoleEventArgument{Name: "Admin"},
+	}
+	permission.UserName = "VSPHERE.LOCAL\\admin"
+
+	attributes = map[string]interface{}{}
+	newAuditEvent(permission).setAttributes(attributes)
+
+	assert.Equal(t, "permissionAdded", attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "VirtualMachine", attributes["vSphereAuditEvent.targetType"])
+	assert.Equal(t, "vm-1", attributes["vSphereAuditEvent.targetName"])
+	assert.Equal(t, "VSPHERE.LOCAL\\operators", attributes["vSphereAuditEvent.principal"])
+	assert.Equal(t, "true", attributes["vSphereAuditEvent.isGroup"])
+	assert.Equal(t, "Admin", attributes["vSphereAuditEvent.role"])
+
+	// given a role updated
+	role := &types.RoleUpdatedEvent{PrivilegesAdded: []string{"VirtualMachine.Interact.PowerOn", "VirtualMachine.Interact.PowerOff"}}
+	role.Role.Name = "Operator"
+
+	attributes = map[string]interface{}{}
+	newAuditEvent(role).setAttributes(attributes)
+
+	assert.Equal(t, "roleUpdated", attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "Role", attributes["vSphereAuditEvent.targetType"])
+	assert.Equal(t, "Operator", attributes["vSphereAuditEvent.targetName"])
+	assert.Equal(t, "VirtualMachine.Interact.PowerOn|VirtualMachine.Interact.PowerOff", attributes["vSphereAuditEvent.privilegesAdded"])
+
+	// given an ESXi account lockout
+	attributes = map[string]interface{}{}
+	newAuditEvent(&types.EventEx{EventTypeId: "esx.audit.account.locked"}).setAttributes(attributes)
+
+	assert.Equal(t, "accountLocked", attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "failure", attributes["vSphereAuditEvent.outcome"])
+
+	// given events not relevant for the audit
+	assert.Nil(t, newAuditEvent(&types.VmPoweredOffEvent{}))
+	assert.Nil(t, newAuditEvent(&types.EventEx{EventTypeId: "com.vmware.vc.HA.HostFailedEvent"}))
+}
+
+func Test_processEvent_audit(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+	cfg.Args.EnableVsphereEvents = true
+	cfg.Args.EnableVsphereAuditEvents = true
+	cfg.Integration, _ = integration.New("test", "dev")
+	e, err := cfg.Integration.Entity("dc", "vsphere-datacenter")
+	require.NoError(t, err)
+	cfg.AddEntity(entityKey(entityTypeDatacenter, "dc"))
+	dc := model.NewDatacenter(&mo.Datacenter{ManagedEntity: mo.ManagedEntity{Name: "dc"}})
+
+	login := &types.UserLoginSessionEvent{IpAddress: "10.0.0.1"}
+	login.FullFormattedMessage = "User admin logged in"
+	poweredOff := &types.VmPoweredOffEvent{}
+	poweredOff.FullFormattedMessage = "vm powered off"
+
+	ed := &events.EventDispacher{Events: []types.BaseEvent{login, poweredOff}}
+	err = processEvent(cfg, ed, &entityRouter{config: cfg, dc: dc})
+	require.NoError(t, err)
+
+	require.Len(t, e.Events, 2)
+	assert.Equal(t, "vSphereAuditEvent", e.Events[0].Category)
+	assert.Equal(t, "login", e.Events[0].Attributes["vSphereAuditEvent.action"])
+	assert.Equal(t, "vSphereEvent", e.Events[1].Category)
+}
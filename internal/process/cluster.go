@@ -108,6 +108,14 @@ func createClusterSamples(config *config.Config) {
 			checkError(config.Logrus, ms.SetMetric("dasConfig.vmComponentProtecting", cluster.Configuration.DasConfig.VmComponentProtecting, metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("dasConfig.hbDatastoreCandidatePolicy", cluster.Configuration.DasConfig.HBDatastoreCandidatePolicy, metric.ATTRIBUTE))
 
+			// vSAN
+			if vc, ok := dc.VsanClusters[cluster.Self]; ok {
+				setVsanClusterMetrics(config, ms, vc)
+			}
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, cluster.Self)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(cluster.Self)
@@ -123,6 +131,7 @@ func createClusterSamples(config *config.Config) {
 				for _, perfMetric := range perfMetrics {
 					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
 				}
+				createPerfInstanceSamples(config, e, entityTypeCluster, perfMetrics)
 			}
 		}
 	}
@@ -11,7 +11,6 @@ import (
 
 	eventSDK "github.com/newrelic/infra-integrations-sdk/v3/data/event"
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
-	"github.com/newrelic/infra-integrations-sdk/v3/integration"
 	"github.com/newrelic/nri-vsphere/internal/events"
 	logrus "github.com/sirupsen/logrus"
 )
@@ -47,13 +46,6 @@ func createDatacenterSamples(config *config.Config) {
 			continue
 		}
 
-		if config.EventCollectionEnabled() {
-			err = processEvent(config, dc.EventDispacher, dcEntity)
-			if err != nil {
-				config.Logrus.WithError(err).WithField("datacenterName", entityName).WithField("uniqueIdentifier", uniqueIdentifier).Error("failed to create metricSet")
-			}
-		}
-
 		for _, datastore := range dc.Datastores {
 			totalDatastoreCapacity = totalDatastoreCapacity + datastore.Summary.Capacity
 			totalDatastoreFreeSpace = totalDatastoreFreeSpace + datastore.Summary.FreeSpace
@@ -105,6 +97,9 @@ func createDatacenterSamples(config *config.Config) {
 		checkError(config.Logrus, ms.SetMetric("resourcePools", countResourcePools, metric.GAUGE))
 		checkError(config.Logrus, ms.SetMetric("clusters", len(dc.Clusters), metric.GAUGE))
 
+		// Triggered alarms
+		createTriggeredAlarmSamples(config, dcEntity, dc, dc.Datacenter.Self)
+
 		// Tags
 		if config.TagCollectionEnabled() {
 			tagsByCategory := config.TagCollector.GetTagsByCategories(dc.Datacenter.Self)
@@ -117,7 +112,9 @@ func createDatacenterSamples(config *config.Config) {
 	}
 }
 
-func processEvent(config *config.Config, ed *events.EventDispacher, entity *integration.Entity) error {
+// processEvent adds each event to the entity of the vm, host, datastore or cluster it refers to, in that order,
+// or to the datacenter entity otherwise
+func processEvent(config *config.Config, ed *events.EventDispacher, router *entityRouter) error {
 
 	if ed == nil {
 		return fmt.Errorf("not expecting empty EventDispacher")
@@ -133,11 +130,17 @@ func processEvent(config *config.Config, ed *events.EventDispacher, entity *inte
 			Summary:  e.FullFormattedMessage,
 			Category: "vSphereEvent",
 			Attributes: map[string]interface{}{
+				"vSphereEvent.type":     events.EventTypeName(be),
+				"vSphereEvent.key":      int(e.Key),
+				"vSphereEvent.chainId":  int(e.ChainId),
 				"vSphereEvent.userName": e.UserName,
 				"vSphereEvent.date":     e.CreatedTime.Format(time.RFC1123),
 				"timestamp":             e.CreatedTime.Unix(),
 			},
 		}
+		if severity, ok := ed.Severities[e.Key]; ok {
+			ev.Attributes["vSphereEvent.severity"] = severity
+		}
 		if e.Vm != nil {
 			ev.Attributes["vSphereEvent.vm"] = e.Vm.Name
 		}
@@ -156,6 +159,23 @@ func processEvent(config *config.Config, ed *events.EventDispacher, entity *inte
 		if e.Net != nil {
 			ev.Attributes["vSphereEvent.network"] = e.Net.Name
 		}
+		if e.Dvs != nil {
+			ev.Attributes["vSphereEvent.distributedSwitch"] = e.Dvs.Name
+		}
+		setEventTypeAttributes(ev.Attributes, be)
+
+		// security relevant events are reported apart, in the audit feed
+		if config.AuditEventsEnabled() {
+			if audit := newAuditEvent(be); audit != nil {
+				ev.Category = auditEventCategory
+				audit.setAttributes(ev.Attributes)
+			}
+		}
+
+		entity := router.entityFor(vmRef(e.Vm), hostRef(e.Host), datastoreRef(e.Ds), computeResourceRef(e.ComputeResource))
+		if entity == nil {
+			continue
+		}
 		err := entity.AddEvent(ev)
 
 		if err != nil {
@@ -48,6 +48,9 @@ func createDatastoreSamples(config *config.Config) {
 			checkError(config.Logrus, ms.SetMetric("vmCount", len(ds.Vm), metric.GAUGE))
 			checkError(config.Logrus, ms.SetMetric("hostCount", len(ds.Host), metric.GAUGE))
 			checkError(config.Logrus, ms.SetMetric("url", ds.Summary.Url, metric.ATTRIBUTE))
+			if pod := dc.FindStoragePod(ds.Self); pod != nil {
+				checkError(config.Logrus, ms.SetMetric("datastoreClusterName", pod.Name, metric.ATTRIBUTE))
+			}
 			checkError(config.Logrus, ms.SetMetric("capacity", float64(ds.Summary.Capacity)/(1<<30), metric.GAUGE))
 			checkError(config.Logrus, ms.SetMetric("freeSpace", float64(ds.Summary.FreeSpace)/(1<<30), metric.GAUGE))
 			checkError(config.Logrus, ms.SetMetric("uncommitted", float64(ds.Summary.Uncommitted)/(1<<30), metric.GAUGE))
@@ -60,6 +63,9 @@ func createDatastoreSamples(config *config.Config) {
 				}
 			}
 
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, ds.Self)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(ds.Self)
@@ -76,6 +82,7 @@ func createDatastoreSamples(config *config.Config) {
 				for _, perfMetric := range perfMetrics {
 					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
 				}
+				createPerfInstanceSamples(config, e, entityTypeDatastore, perfMetrics)
 			}
 		}
 	}
@@ -0,0 +1,271 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func createDistributedSwitchSamples(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		for _, sw := range dc.DistributedSwitches {
+
+			// filtering here will to avoid sending data to backend
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(sw.Self) {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+
+			entityName := sanitizeEntityName(config, sw.Name, datacenterName)
+
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeDistributedSwitch, entityName, sw.Uuid)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("distributedSwitchName", entityName).WithField("uuid", sw.Uuid).Error("failed to create metricSet")
+				continue
+			}
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			checkError(config.Logrus, ms.SetMetric("name", sw.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("uuid", sw.Uuid, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("overallStatus", string(sw.OverallStatus), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("vmCount", len(sw.Summary.Vm), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("portgroupCount", len(sw.Portgroup), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("portgroupNameList", strings.Join(sw.Summary.PortgroupName, "|"), metric.ATTRIBUTE))
+
+			hostList := ""
+			for _, hostReference := range sw.Summary.HostMember {
+				if h, ok := dc.Hosts[hostReference]; ok && h.Summary.Config.Name != "" {
+					hostList += h.Summary.Config.Name + "|"
+				}
+			}
+			hostList = strings.TrimSuffix(hostList, "|")
+			checkError(config.Logrus, ms.SetMetric("hostCount", len(sw.Summary.HostMember), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("hostNameList", hostList, metric.ATTRIBUTE))
+
+			if sw.Config != nil {
+				cfg := sw.Config.GetDVSConfigInfo()
+				checkError(config.Logrus, ms.SetMetric("version", cfg.ProductInfo.Version, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("vendor", cfg.ProductInfo.Vendor, metric.ATTRIBUTE))
+
+				if policy, ok := cfg.UplinkPortPolicy.(*types.DVSNameArrayUplinkPortPolicy); ok {
+					checkError(config.Logrus, ms.SetMetric("uplinkCount", len(policy.UplinkPortName), metric.GAUGE))
+					checkError(config.Logrus, ms.SetMetric("uplinkNameList", strings.Join(policy.UplinkPortName, "|"), metric.ATTRIBUTE))
+				}
+
+				hostsNotUp := 0
+				for _, member := range cfg.Host {
+					if member.Status != string(types.DistributedVirtualSwitchHostMemberHostComponentStateUp) {
+						hostsNotUp++
+					}
+				}
+				checkError(config.Logrus, ms.SetMetric("hostNotUpCount", hostsNotUp, metric.GAUGE))
+
+				if cfg.NetworkResourceManagementEnabled != nil {
+					checkError(config.Logrus, ms.SetMetric("nioc.enabled", fmt.Sprintf("%t", *cfg.NetworkResourceManagementEnabled), metric.ATTRIBUTE))
+				}
+				if cfg.NetworkResourceControlVersion != "" {
+					checkError(config.Logrus, ms.SetMetric("nioc.version", cfg.NetworkResourceControlVersion, metric.ATTRIBUTE))
+				}
+
+				for _, hc := range cfg.HealthCheckConfig {
+					enabled := hc.GetDVSHealthCheckConfig().Enable
+					if enabled == nil {
+						continue
+					}
+					switch hc.(type) {
+					case *types.VMwareDVSVlanMtuHealthCheckConfig:
+						checkError(config.Logrus, ms.SetMetric("healthCheck.vlanMtu.enabled", fmt.Sprintf("%t", *enabled), metric.ATTRIBUTE))
+					case *types.VMwareDVSTeamingHealthCheckConfig:
+						checkError(config.Logrus, ms.SetMetric("healthCheck.teaming.enabled", fmt.Sprintf("%t", *enabled), metric.ATTRIBUTE))
+					}
+				}
+
+				if vmwareCfg, ok := sw.Config.(*types.VMwareDVSConfigInfo); ok {
+					checkError(config.Logrus, ms.SetMetric("mtu", vmwareCfg.MaxMtu, metric.GAUGE))
+				}
+			}
+
+			if sw.Runtime != nil {
+				var mtuMismatch, vlanNotTrunked, teamingMismatch int
+				for _, member := range sw.Runtime.HostMemberRuntime {
+					for _, result := range member.HealthCheckResult {
+						switch r := result.(type) {
+						case *types.VMwareDVSMtuHealthCheckResult:
+							if r.MtuMismatch {
+								mtuMismatch++
+							}
+						case *types.VMwareDVSVlanHealthCheckResult:
+							if len(r.UntrunkedVlan) > 0 {
+								vlanNotTrunked++
+							}
+						case *types.VMwareDVSTeamingHealthCheckResult:
+							if strings.HasSuffix(strings.ToLower(r.TeamingStatus), "mismatch") {
+								teamingMismatch++
+							}
+						}
+					}
+				}
+				checkError(config.Logrus, ms.SetMetric("healthCheck.mtuMismatchCount", mtuMismatch, metric.GAUGE))
+				checkError(config.Logrus, ms.SetMetric("healthCheck.vlanNotTrunkedCount", vlanNotTrunked, metric.GAUGE))
+				checkError(config.Logrus, ms.SetMetric("healthCheck.teamingMismatchCount", teamingMismatch, metric.GAUGE))
+			}
+
+			setPortCounts(config, ms, dc, sw.Self)
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, sw.Self)
+
+			// Tags
+			if config.TagCollectionEnabled() {
+				tagsByCategory := config.TagCollector.GetTagsByCategories(sw.Self)
+				for k, v := range tagsByCategory {
+					checkError(config.Logrus, ms.SetMetric(tagsPrefix+k, v, metric.ATTRIBUTE))
+					// add tags to inventory due to the inventory workaround
+					addTagsToInventory(config, e, k, v)
+				}
+			}
+		}
+	}
+}
+
+func createDistributedPortgroupSamples(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		for _, pg := range dc.DistributedPortgroups {
+
+			// filtering here will to avoid sending data to backend
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(pg.Self) {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+
+			entityName := sanitizeEntityName(config, pg.Name, datacenterName)
+
+			// portgroup keys are only unique within the switch
+			portgroupID := pg.Config.Key
+			var sw string
+			if pg.Config.DistributedVirtualSwitch != nil {
+				if s, ok := dc.DistributedSwitches[*pg.Config.DistributedVirtualSwitch]; ok {
+					sw = s.Name
+					portgroupID = s.Uuid + ":" + pg.Config.Key
+				}
+			}
+
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeDistributedPortgroup, entityName, portgroupID)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("distributedPortgroupName", entityName).WithField("portgroupID", portgroupID).Error("failed to create metricSet")
+				continue
+			}
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			checkError(config.Logrus, ms.SetMetric("name", pg.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("key", pg.Config.Key, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("distributedSwitchName", sw, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("overallStatus", string(pg.OverallStatus), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("type", pg.Config.Type, metric.ATTRIBUTE))
+			if pg.Config.Uplink != nil {
+				checkError(config.Logrus, ms.SetMetric("uplink", fmt.Sprintf("%t", *pg.Config.Uplink), metric.ATTRIBUTE))
+			}
+
+			if setting, ok := pg.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting); ok {
+				vlanType, vlanID := vlanConfig(setting.Vlan)
+				checkError(config.Logrus, ms.SetMetric("vlanType", vlanType, metric.ATTRIBUTE))
+				if vlanID != "" {
+					checkError(config.Logrus, ms.SetMetric("vlanId", vlanID, metric.ATTRIBUTE))
+				}
+			}
+
+			hostList := ""
+			for _, host := range pg.Host {
+				if h, ok := dc.Hosts[host]; ok && h.Summary.Config.Name != "" {
+					hostList += h.Summary.Config.Name + "|"
+				}
+			}
+			hostList = strings.TrimSuffix(hostList, "|")
+			checkError(config.Logrus, ms.SetMetric("hostCount", len(pg.Host), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("hostNameList", hostList, metric.ATTRIBUTE))
+
+			vmList := ""
+			for _, vm := range pg.Vm {
+				if v, ok := dc.VirtualMachines[vm]; ok {
+					vmList += v.Name + "|"
+				}
+			}
+			vmList = strings.TrimSuffix(vmList, "|")
+			checkError(config.Logrus, ms.SetMetric("vmCount", len(pg.Vm), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("vmNameList", vmList, metric.ATTRIBUTE))
+
+			setPortCounts(config, ms, dc, pg.Self)
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, pg.Self)
+
+			// Tags
+			if config.TagCollectionEnabled() {
+				tagsByCategory := config.TagCollector.GetTagsByCategories(pg.Self)
+				for k, v := range tagsByCategory {
+					checkError(config.Logrus, ms.SetMetric(tagsPrefix+k, v, metric.ATTRIBUTE))
+					// add tags to inventory due to the inventory workaround
+					addTagsToInventory(config, e, k, v)
+				}
+			}
+		}
+	}
+}
+
+func setPortCounts(config *config.Config, ms *metric.Set, dc *model.Datacenter, ref types.ManagedObjectReference) {
+	counts, ok := dc.DistributedPortCounts[ref]
+	if !ok {
+		return
+	}
+	checkError(config.Logrus, ms.SetMetric("portCount", counts.Total, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("portUsedCount", counts.Used, metric.GAUGE))
+	checkError(config.Logrus, ms.SetMetric("portBlockedCount", counts.Blocked, metric.GAUGE))
+}
+
+// vlanConfig returns the type of VLAN configuration along with the VLAN ids, if any
+func vlanConfig(vlan types.BaseVmwareDistributedVirtualSwitchVlanSpec) (string, string) {
+	switch v := vlan.(type) {
+	case *types.VmwareDistributedVirtualSwitchVlanIdSpec:
+		if v.VlanId == 0 {
+			return "none", ""
+		}
+		return "vlan", fmt.Sprintf("%d", v.VlanId)
+	case *types.VmwareDistributedVirtualSwitchTrunkVlanSpec:
+		var ranges []string
+		for _, r := range v.VlanId {
+			if r.Start == r.End {
+				ranges = append(ranges, fmt.Sprintf("%d", r.Start))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("%d-%d", r.Start, r.End))
+			}
+		}
+		return "trunk", strings.Join(ranges, "|")
+	case *types.VmwareDistributedVirtualSwitchPvlanSpec:
+		return "pvlan", fmt.Sprintf("%d", v.PvlanId)
+	}
+	return "none", ""
+}
@@ -0,0 +1,46 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createDistributedSwitchSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.VirtualMachines(cfg)
+		collect.DistributedSwitches(cfg)
+
+		createDistributedSwitchSamples(cfg)
+		createDistributedPortgroupSamples(cfg)
+
+		// then
+		samples := samplesByEventType(cfg.Integration.Entities...)
+
+		require.Len(t, samples["VSphereDistributedSwitchSample"], 1)
+		sw := samples["VSphereDistributedSwitchSample"][0]
+		assert.Equal(t, "DVS0", sw["name"])
+		assert.Equal(t, "DC0", sw["datacenterName"])
+		assert.NotEmpty(t, sw["hostNameList"])
+		assert.Contains(t, sw, "portCount")
+		assert.Contains(t, sw, "portUsedCount")
+		assert.Contains(t, sw, "portBlockedCount")
+
+		require.NotEmpty(t, samples["VSphereDistributedPortgroupSample"])
+		for _, pg := range samples["VSphereDistributedPortgroupSample"] {
+			assert.Equal(t, "DVS0", pg["distributedSwitchName"])
+			assert.Contains(t, pg, "vlanType")
+		}
+		return nil
+	})
+}
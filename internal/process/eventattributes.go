@@ -0,0 +1,129 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const eventAttributePrefix = "vSphereEvent."
+
+// Fields holding an entity argument shared by several event types, such as the source and destination of the
+// migration and relocation events, reported by attribute name
+var eventArgumentFields = []struct {
+	field     string
+	attribute string
+}{
+	{"SourceHost", "sourceHost"},
+	{"SourceDatacenter", "sourceDatacenter"},
+	{"SourceDatastore", "sourceDatastore"},
+	{"DestHost", "destHost"},
+	{"DestDatacenter", "destDatacenter"},
+	{"DestDatastore", "destDatastore"},
+}
+
+// setEventTypeAttributes adds the fields specific to the type of the event to the attributes
+func setEventTypeAttributes(attributes map[string]interface{}, be types.BaseEvent) {
+	switch e := be.(type) {
+	case *types.EventEx:
+		attributes[eventAttributePrefix+"eventTypeId"] = e.EventTypeId
+		if e.Message != "" {
+			attributes[eventAttributePrefix+"message"] = e.Message
+		}
+		if e.ObjectId != "" {
+			attributes[eventAttributePrefix+"objectId"] = e.ObjectId
+			attributes[eventAttributePrefix+"objectType"] = e.ObjectType
+			attributes[eventAttributePrefix+"objectName"] = e.ObjectName
+		}
+		if e.Fault != nil {
+			attributes[eventAttributePrefix+"fault"] = e.Fault.LocalizedMessage
+		}
+		for _, argument := range e.Arguments {
+			attributes[eventAttributePrefix+"arg."+argument.Key] = eventArgumentValue(argument.Value)
+		}
+	case *types.ExtendedEvent:
+		attributes[eventAttributePrefix+"eventTypeId"] = e.EventTypeId
+		attributes[eventAttributePrefix+"objectId"] = e.ManagedObject.Value
+		attributes[eventAttributePrefix+"objectType"] = e.ManagedObject.Type
+		for _, pair := range e.Data {
+			attributes[eventAttributePrefix+"arg."+pair.Key] = pair.Value
+		}
+	case *types.AlarmStatusChangedEvent:
+		attributes[eventAttributePrefix+"alarm"] = e.Alarm.Name
+		attributes[eventAttributePrefix+"from"] = e.From
+		attributes[eventAttributePrefix+"to"] = e.To
+		attributes[eventAttributePrefix+"entity"] = e.Entity.Name
+	}
+
+	v := reflect.ValueOf(be)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+
+	for _, f := range eventArgumentFields {
+		if name := entityArgumentName(v.FieldByName(f.field)); name != "" {
+			attributes[eventAttributePrefix+f.attribute] = name
+		}
+	}
+
+	// failure events report the fault that caused them, while others like host disconnections or HA resets a
+	// description of why they happened
+	if reason := eventReason(v.FieldByName("Reason")); reason != "" {
+		attributes[eventAttributePrefix+"reason"] = reason
+	}
+}
+
+// entityArgumentName returns the name of the entity held by an event argument field, if any
+func entityArgumentName(field reflect.Value) string {
+	if !field.IsValid() {
+		return ""
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if !field.CanAddr() {
+		return ""
+	}
+	if argument, ok := field.Addr().Interface().(types.BaseEntityEventArgument); ok {
+		return argument.GetEntityEventArgument().Name
+	}
+	return ""
+}
+
+// eventReason returns the reason held by a Reason field, either a plain string or a fault
+func eventReason(field reflect.Value) string {
+	if !field.IsValid() {
+		return ""
+	}
+	switch reason := field.Interface().(type) {
+	case string:
+		return reason
+	case types.LocalizedMethodFault:
+		return reason.LocalizedMessage
+	case *types.LocalizedMethodFault:
+		if reason != nil {
+			return reason.LocalizedMessage
+		}
+	}
+	return ""
+}
+
+// eventArgumentValue returns the value of an EventEx argument as a string, or the entity name when it refers to one
+func eventArgumentValue(value types.AnyType) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case types.BaseEntityEventArgument:
+		return v.GetEntityEventArgument().Name
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,72 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_setEventTypeAttributes(t *testing.T) {
+	// given a DRS migration
+	migrated := &types.DrsVmMigratedEvent{
+		VmMigratedEvent: types.VmMigratedEvent{
+			SourceHost:      types.HostEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "host-a"}},
+			SourceDatastore: &types.DatastoreEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "ds-a"}},
+		},
+	}
+	attributes := map[string]interface{}{}
+	setEventTypeAttributes(attributes, migrated)
+
+	assert.Equal(t, "DrsVmMigratedEvent", events.EventTypeName(migrated))
+	assert.Equal(t, "host-a", attributes["vSphereEvent.sourceHost"])
+	assert.Equal(t, "ds-a", attributes["vSphereEvent.sourceDatastore"])
+	assert.NotContains(t, attributes, "vSphereEvent.sourceDatacenter")
+
+	// given a failed migration
+	failed := &types.VmFailedMigrateEvent{
+		DestHost: types.HostEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "host-b"}},
+		Reason:   types.LocalizedMethodFault{LocalizedMessage: "Insufficient resources"},
+	}
+	attributes = map[string]interface{}{}
+	setEventTypeAttributes(attributes, failed)
+
+	assert.Equal(t, "host-b", attributes["vSphereEvent.destHost"])
+	assert.Equal(t, "Insufficient resources", attributes["vSphereEvent.reason"])
+
+	// given a host disconnection
+	attributes = map[string]interface{}{}
+	setEventTypeAttributes(attributes, &types.HostDisconnectedEvent{Reason: "heartbeat"})
+
+	assert.Equal(t, "heartbeat", attributes["vSphereEvent.reason"])
+
+	// given an extended event with arguments
+	ex := &types.EventEx{
+		EventTypeId: "com.vmware.vc.HA.HostFailedEvent",
+		Severity:    "error",
+		Arguments: []types.KeyAnyValue{
+			{Key: "hostName", Value: "host-c"},
+			{Key: "vm", Value: &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "vm-1"}}},
+			{Key: "count", Value: int32(2)},
+		},
+	}
+	attributes = map[string]interface{}{}
+	setEventTypeAttributes(attributes, ex)
+
+	assert.Equal(t, "EventEx", events.EventTypeName(ex))
+	assert.Equal(t, "com.vmware.vc.HA.HostFailedEvent", attributes["vSphereEvent.eventTypeId"])
+	assert.Equal(t, "host-c", attributes["vSphereEvent.arg.hostName"])
+	assert.Equal(t, "vm-1", attributes["vSphereEvent.arg.vm"])
+	assert.Equal(t, "2", attributes["vSphereEvent.arg.count"])
+
+	// given an alarm status change
+	alarm := &types.AlarmStatusChangedEvent{From: "green", To: "red"}
+	alarm.Alarm.Name = "Host CPU usage"
+	attributes = map[string]interface{}{}
+	setEventTypeAttributes(attributes, alarm)
+
+	assert.Equal(t, "Host CPU usage", attributes["vSphereEvent.alarm"])
+	assert.Equal(t, "green", attributes["vSphereEvent.from"])
+	assert.Equal(t, "red", attributes["vSphereEvent.to"])
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// entityRouter finds the entity of the objects referenced by events and tasks among the entities reported by the
+// endpoint, falling back to the datacenter entity for objects not reported, such as those left out by the tag filter
+type entityRouter struct {
+	config *config.Config
+	dc     *model.Datacenter
+}
+
+// createEventSamples adds the events and tasks collected in each datacenter to the entity they refer to. It runs
+// once every other sample has been created, since events are routed to the entities reported by the endpoint.
+func createEventSamples(config *config.Config) {
+	if !config.IsVcenterAPIType {
+		return
+	}
+
+	for _, dc := range config.Datacenters {
+		router := &entityRouter{
+			config: config,
+			dc:     dc,
+		}
+		if !config.HasEntity(entityKey(entityTypeDatacenter, router.datacenterName())) {
+			config.Logrus.WithField("datacenterName", dc.Datacenter.Name).Warn("datacenter entity not found, events are not reported")
+			continue
+		}
+
+		// events might not have been collected in this run when running in daemon mode
+		if config.EventCollectionEnabled() && dc.EventDispacher != nil {
+			err := processEvent(config, dc.EventDispacher, router)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("datacenterName", dc.Datacenter.Name).Error("failed to add events")
+			}
+		}
+		if config.TaskCollectionEnabled() && dc.TaskDispacher != nil {
+			err := processTasks(config, dc.TaskDispacher, router)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("datacenterName", dc.Datacenter.Name).Error("failed to add task events")
+			}
+		}
+	}
+}
+
+// entityFor returns the entity of the first object reported, in the order given, or the datacenter entity. It
+// returns nil only if the entity could not be created.
+func (r *entityRouter) entityFor(refs ...*types.ManagedObjectReference) *integration.Entity {
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		if typeEntity, uniqueIdentifier := r.identifierOf(*ref); uniqueIdentifier != "" {
+			if e := r.entity(typeEntity, uniqueIdentifier); e != nil {
+				return e
+			}
+		}
+	}
+	return r.entity(entityTypeDatacenter, r.datacenterName())
+}
+
+func (r *entityRouter) datacenterName() string {
+	return sanitizeEntityName(r.config, r.dc.Datacenter.Name, "")
+}
+
+// entity returns the entity with the given type and identifier if it was reported by the endpoint, or nil. Entities
+// are fetched from the integration since they are created again after being published.
+func (r *entityRouter) entity(typeEntity string, uniqueIdentifier string) *integration.Entity {
+	if !r.config.HasEntity(entityKey(typeEntity, uniqueIdentifier)) {
+		return nil
+	}
+	e, err := r.config.Integration.Entity(uniqueIdentifier, "vsphere-"+strings.ToLower(typeEntity))
+	if err != nil {
+		r.config.Logrus.WithError(err).Error("failed to create entity")
+		return nil
+	}
+	return e
+}
+
+// identifierOf returns the type and unique identifier of the entity of the object, the same used when creating its
+// sample, or an empty identifier
+func (r *entityRouter) identifierOf(ref types.ManagedObjectReference) (string, string) {
+	switch ref.Type {
+	case "VirtualMachine":
+		if vm, ok := r.dc.VirtualMachines[ref]; ok && vm.Config != nil {
+			return entityTypeVm, vm.Config.InstanceUuid
+		}
+	case "HostSystem":
+		if host, ok := r.dc.Hosts[ref]; ok && host.Summary.Hardware != nil {
+			return entityTypeHost, host.Summary.Hardware.Uuid
+		}
+	case "Datastore":
+		if ds, ok := r.dc.Datastores[ref]; ok {
+			return entityTypeDatastore, ds.Summary.Url
+		}
+	case "ClusterComputeResource":
+		if cluster, ok := r.dc.Clusters[ref]; ok {
+			return entityTypeCluster, sanitizeEntityName(r.config, cluster.Name, r.dc.Datacenter.Name)
+		}
+	}
+	return "", ""
+}
+
+func vmRef(argument *types.VmEventArgument) *types.ManagedObjectReference {
+	if argument == nil {
+		return nil
+	}
+	return &argument.Vm
+}
+
+func hostRef(argument *types.HostEventArgument) *types.ManagedObjectReference {
+	if argument == nil {
+		return nil
+	}
+	return &argument.Host
+}
+
+func datastoreRef(argument *types.DatastoreEventArgument) *types.ManagedObjectReference {
+	if argument == nil {
+		return nil
+	}
+	return &argument.Datastore
+}
+
+func computeResourceRef(argument *types.ComputeResourceEventArgument) *types.ManagedObjectReference {
+	if argument == nil {
+		return nil
+	}
+	return &argument.ComputeResource
+}
+
+// entityKey returns the key of an entity created with createNewEntityWithMetricSet
+func entityKey(typeEntity string, uniqueIdentifier string) string {
+	return "vsphere-" + strings.ToLower(typeEntity) + ":" + uniqueIdentifier
+}
@@ -0,0 +1,169 @@
+package process
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/events"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createEventSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+		vm := view.NewManager(vc)
+
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Args.EnableVsphereEvents = true
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+
+		collect.VirtualMachines(cfg)
+		collect.Hosts(cfg)
+
+		dc := cfg.Datacenters[0]
+		var vmRef, hostRef types.ManagedObjectReference
+		for ref := range dc.VirtualMachines {
+			vmRef = ref
+			break
+		}
+		for ref := range dc.Hosts {
+			hostRef = ref
+			break
+		}
+
+		// given events on a vm, on a host and on a network, which has no entity to be routed to
+		poweredOff := &types.VmPoweredOffEvent{}
+		poweredOff.FullFormattedMessage = "vm powered off"
+		poweredOff.Vm = &types.VmEventArgument{Vm: vmRef}
+		poweredOff.Host = &types.HostEventArgument{Host: hostRef}
+
+		disconnected := &types.HostConnectionLostEvent{}
+		disconnected.FullFormattedMessage = "host connection lost"
+		disconnected.Host = &types.HostEventArgument{Host: hostRef}
+
+		other := &types.GeneralUserEvent{}
+		other.FullFormattedMessage = "network event"
+		other.Net = &types.NetworkEventArgument{Network: types.ManagedObjectReference{Type: "Network", Value: "network-1"}}
+
+		dc.EventDispacher = &events.EventDispacher{Events: []types.BaseEvent{poweredOff, disconnected, other}}
+
+		// when
+		ProcessData(cfg)
+
+		// then
+		eventsByNamespace := map[string][]string{}
+		for _, e := range cfg.Integration.Entities {
+			for _, ev := range e.Events {
+				eventsByNamespace[e.Metadata.Namespace] = append(eventsByNamespace[e.Metadata.Namespace], ev.Summary)
+			}
+		}
+
+		assert.Equal(t, []string{"vm powered off"}, eventsByNamespace["vsphere-vm"])
+		assert.Equal(t, []string{"host connection lost"}, eventsByNamespace["vsphere-host"])
+		assert.Equal(t, []string{"network event"}, eventsByNamespace["vsphere-datacenter"])
+		return nil
+	})
+}
+
+func Test_createEventSamples_MultipleEndpoints(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		base := &config.Config{Logrus: logrus.StandardLogger()}
+		base.Integration, _ = integration.New("test", "dev")
+
+		// given two endpoints processed at the same time, each one with an event not referring to any entity
+		var endpoints []*config.Config
+		for _, location := range []string{"east", "west"} {
+			vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+			require.NoError(t, err)
+			vm := view.NewManager(vc)
+
+			cfg := base.ForEndpoint(config.Endpoint{URL: vc.URL().String(), DatacenterLocation: location})
+			cfg.VMWareClient, cfg.ViewManager, cfg.IsVcenterAPIType = vmClient, vm, true
+			cfg.Args.EnableVsphereEvents = true
+			cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+			collect.VirtualMachines(cfg)
+			collect.Hosts(cfg)
+
+			ev := &types.GeneralUserEvent{}
+			ev.FullFormattedMessage = "event of " + location
+			cfg.Datacenters[0].EventDispacher = &events.EventDispacher{Events: []types.BaseEvent{ev}}
+			endpoints = append(endpoints, cfg)
+		}
+
+		// when
+		var wg sync.WaitGroup
+		wg.Add(len(endpoints))
+		for _, cfg := range endpoints {
+			go func(cfg *config.Config) {
+				defer wg.Done()
+				ProcessData(cfg)
+			}(cfg)
+		}
+		wg.Wait()
+
+		// then each event is attached to the datacenter entity of its own endpoint
+		eventsByEntity := map[string][]string{}
+		for _, e := range base.Integration.Entities {
+			for _, ev := range e.Events {
+				eventsByEntity[e.Metadata.Name] = append(eventsByEntity[e.Metadata.Name], ev.Summary)
+			}
+		}
+		assert.Equal(t, map[string][]string{
+			"east:dc0": {"event of east"},
+			"west:dc0": {"event of west"},
+		}, eventsByEntity)
+		return nil
+	})
+}
+
+func Test_ProcessEvents_AfterPublish(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+		require.NoError(t, err)
+		vm := view.NewManager(vc)
+
+		cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+		cfg.Args.EnableVsphereEvents = true
+		cfg.Integration, _ = integration.New("test", "dev")
+		cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+		collect.VirtualMachines(cfg)
+
+		// given the samples already published
+		ProcessSamples(cfg)
+		cfg.Integration.Clear()
+
+		// when only events are collected
+		dc := cfg.Datacenters[0]
+		var vmRef types.ManagedObjectReference
+		for ref := range dc.VirtualMachines {
+			vmRef = ref
+			break
+		}
+		poweredOff := &types.VmPoweredOffEvent{}
+		poweredOff.FullFormattedMessage = "vm powered off"
+		poweredOff.Vm = &types.VmEventArgument{Vm: vmRef}
+		dc.EventDispacher = &events.EventDispacher{Events: []types.BaseEvent{poweredOff}}
+		ProcessEvents(cfg)
+
+		// then the event is attached to the vm entity and no sample is published again
+		require.Len(t, cfg.Integration.Entities, 1)
+		e := cfg.Integration.Entities[0]
+		assert.Equal(t, "vsphere-vm", e.Metadata.Namespace)
+		assert.Len(t, e.Events, 1)
+		assert.Empty(t, e.Metrics)
+		return nil
+	})
+}
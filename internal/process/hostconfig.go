@@ -0,0 +1,129 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Keys of the host services whose state is reported
+var hostServices = map[string]string{
+	"ntpd":    "ntp",
+	"TSM-SSH": "ssh",
+	"TSM":     "shell",
+}
+
+// syslogHostOption is the advanced option holding the remote syslog targets of the host
+const syslogHostOption = "Syslog.global.logHost"
+
+// Identifier types reported by the hardware holding the serial number of the host
+var serialNumberIdentifiers = []string{"SerialNumberTag", "ServiceTag", "EnclosureSerialNumberTag"}
+
+// setHostConfigMetrics adds the product, hardware and hardening configuration of the host to its sample
+func setHostConfigMetrics(config *config.Config, ms *metric.Set, host *mo.HostSystem) {
+	// product
+	product := host.Summary.Config.Product
+	if host.Config != nil {
+		product = &host.Config.Product
+	}
+	if product != nil {
+		checkError(config.Logrus, ms.SetMetric("product.name", product.FullName, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("product.version", product.Version, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("product.build", product.Build, metric.ATTRIBUTE))
+		// the update level is only available since vSphere 7.0.2
+		if product.PatchLevel != "" {
+			checkError(config.Logrus, ms.SetMetric("product.patchLevel", product.PatchLevel, metric.ATTRIBUTE))
+		}
+	}
+
+	// hardware
+	hardware := host.Summary.Hardware
+	checkError(config.Logrus, ms.SetMetric("hardware.vendor", hardware.Vendor, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("hardware.model", hardware.Model, metric.ATTRIBUTE))
+	checkError(config.Logrus, ms.SetMetric("cpu.model", hardware.CpuModel, metric.ATTRIBUTE))
+	if serialNumber := hostSerialNumber(host); serialNumber != "" {
+		checkError(config.Logrus, ms.SetMetric("hardware.serialNumber", serialNumber, metric.ATTRIBUTE))
+	}
+	if host.Hardware != nil && host.Hardware.BiosInfo != nil {
+		bios := host.Hardware.BiosInfo
+		checkError(config.Logrus, ms.SetMetric("bios.version", bios.BiosVersion, metric.ATTRIBUTE))
+		if bios.ReleaseDate != nil {
+			checkError(config.Logrus, ms.SetMetric("bios.releaseDate", bios.ReleaseDate.Format("2006-01-02"), metric.ATTRIBUTE))
+		}
+	}
+
+	// EVC
+	if host.Summary.CurrentEVCModeKey != "" {
+		checkError(config.Logrus, ms.SetMetric("evc.currentMode", host.Summary.CurrentEVCModeKey, metric.ATTRIBUTE))
+	}
+	if host.Summary.MaxEVCModeKey != "" {
+		checkError(config.Logrus, ms.SetMetric("evc.maxMode", host.Summary.MaxEVCModeKey, metric.ATTRIBUTE))
+	}
+
+	if host.Config == nil {
+		return
+	}
+
+	// NTP
+	if dateTime := host.Config.DateTimeInfo; dateTime != nil && dateTime.NtpConfig != nil {
+		checkError(config.Logrus, ms.SetMetric("ntp.servers", strings.Join(dateTime.NtpConfig.Server, "|"), metric.ATTRIBUTE))
+	}
+
+	// lockdown mode is reported since vSphere 6.0
+	if host.Config.LockdownMode != "" {
+		checkError(config.Logrus, ms.SetMetric("lockdownMode", string(host.Config.LockdownMode), metric.ATTRIBUTE))
+	}
+
+	// services running state and startup policy
+	if host.Config.Service != nil {
+		for _, service := range host.Config.Service.Service {
+			if prefix, ok := hostServices[service.Key]; ok {
+				checkError(config.Logrus, ms.SetMetric(prefix+".running", fmt.Sprintf("%t", service.Running), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric(prefix+".policy", service.Policy, metric.ATTRIBUTE))
+			}
+		}
+	}
+
+	// syslog
+	for _, option := range host.Config.Option {
+		o := option.GetOptionValue()
+		if o.Key != syslogHostOption {
+			continue
+		}
+		if logHost, ok := o.Value.(string); ok {
+			checkError(config.Logrus, ms.SetMetric("syslog.logHost", logHost, metric.ATTRIBUTE))
+		}
+	}
+}
+
+// hostSerialNumber returns the serial number of the host, reported since vSphere 6.7, or the one found in the
+// identifying info of the hardware otherwise
+func hostSerialNumber(host *mo.HostSystem) string {
+	var identifiers []types.HostSystemIdentificationInfo
+	if host.Hardware != nil {
+		if host.Hardware.SystemInfo.SerialNumber != "" {
+			return host.Hardware.SystemInfo.SerialNumber
+		}
+		identifiers = host.Hardware.SystemInfo.OtherIdentifyingInfo
+	}
+	identifiers = append(identifiers, host.Summary.Hardware.OtherIdentifyingInfo...)
+
+	for _, identifierType := range serialNumberIdentifiers {
+		for _, identifier := range identifiers {
+			if identifier.IdentifierType == nil {
+				continue
+			}
+			if identifier.IdentifierType.GetElementDescription().Key == identifierType {
+				return strings.TrimSpace(identifier.IdentifierValue)
+			}
+		}
+	}
+	return ""
+}
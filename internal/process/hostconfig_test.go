@@ -0,0 +1,55 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_setHostConfigMetrics(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		collect.Hosts(cfg)
+
+		// given a host with ntp servers, a syslog target and a serial number
+		var hostName string
+		for _, host := range cfg.Datacenters[0].Hosts {
+			host.Config.DateTimeInfo = &types.HostDateTimeInfo{
+				NtpConfig: &types.HostNtpConfig{Server: []string{"0.pool.ntp.org", "1.pool.ntp.org"}},
+			}
+			host.Config.Option = append(host.Config.Option, &types.OptionValue{Key: "Syslog.global.logHost", Value: "udp://syslog.example.com:514"})
+			host.Hardware.SystemInfo.SerialNumber = "ABC123"
+			hostName = host.Summary.Config.Name
+			break
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		sample := sampleWith(samplesByEventType(cfg.Integration.Entities...)["VSphereHostSample"], "hypervisorHostname", hostName)
+		require.NotNil(t, sample)
+
+		assert.NotEmpty(t, sample["product.version"])
+		assert.NotEmpty(t, sample["product.build"])
+		assert.NotEmpty(t, sample["hardware.vendor"])
+		assert.NotEmpty(t, sample["hardware.model"])
+		assert.NotEmpty(t, sample["cpu.model"])
+		assert.Equal(t, "ABC123", sample["hardware.serialNumber"])
+		assert.NotEmpty(t, sample["bios.version"])
+		assert.Equal(t, "0.pool.ntp.org|1.pool.ntp.org", sample["ntp.servers"])
+		assert.Equal(t, "lockdownDisabled", sample["lockdownMode"])
+		assert.Contains(t, sample, "ntp.running")
+		assert.Contains(t, sample, "ssh.running")
+		assert.Contains(t, sample, "shell.policy")
+		assert.Equal(t, "udp://syslog.example.com:514", sample["syslog.logHost"])
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// createPhysicalNicSamples adds a sample for each physical nic of the host to the host entity
+func createPhysicalNicSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem) {
+	if host.Config == nil || host.Config.Network == nil {
+		return
+	}
+	network := host.Config.Network
+
+	// standard and distributed switches list the keys of the physical nics used as uplinks
+	virtualSwitches := make(map[string][]string)
+	for _, vswitch := range network.Vswitch {
+		for _, pnic := range vswitch.Pnic {
+			virtualSwitches[pnic] = append(virtualSwitches[pnic], vswitch.Name)
+		}
+	}
+	distributedSwitches := make(map[string][]string)
+	for _, proxySwitch := range network.ProxySwitch {
+		for _, pnic := range proxySwitch.Pnic {
+			distributedSwitches[pnic] = append(distributedSwitches[pnic], proxySwitch.DvsName)
+		}
+	}
+
+	for _, pnic := range network.Pnic {
+		ms := e.NewMetricSet("VSphere" + sampleTypePnicHost + "Sample")
+
+		checkError(config.Logrus, ms.SetMetric("name", pnic.Device, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("key", pnic.Key, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("macAddress", pnic.Mac, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("pci", pnic.Pci, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("driver", pnic.Driver, metric.ATTRIBUTE))
+		if pnic.DriverVersion != "" {
+			checkError(config.Logrus, ms.SetMetric("driverVersion", pnic.DriverVersion, metric.ATTRIBUTE))
+		}
+
+		// the link speed is only reported while the link is up
+		linkUp := pnic.LinkSpeed != nil
+		checkError(config.Logrus, ms.SetMetric("linkUp", fmt.Sprintf("%t", linkUp), metric.ATTRIBUTE))
+		if linkUp {
+			duplex := "half"
+			if pnic.LinkSpeed.Duplex {
+				duplex = "full"
+			}
+			checkError(config.Logrus, ms.SetMetric("linkSpeedMb", int(pnic.LinkSpeed.SpeedMb), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("duplex", duplex, metric.ATTRIBUTE))
+		}
+
+		if names, ok := virtualSwitches[pnic.Key]; ok {
+			checkError(config.Logrus, ms.SetMetric("virtualSwitchName", strings.Join(names, "|"), metric.ATTRIBUTE))
+		}
+		if names, ok := distributedSwitches[pnic.Key]; ok {
+			checkError(config.Logrus, ms.SetMetric("distributedSwitchName", strings.Join(names, "|"), metric.ATTRIBUTE))
+		}
+	}
+}
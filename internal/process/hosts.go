@@ -101,8 +101,18 @@ func createHostSamples(config *config.Config) {
 			networkList = strings.TrimSuffix(networkList, "|")
 			checkError(config.Logrus, ms.SetMetric("networkNameList", networkList, metric.ATTRIBUTE))
 
+			distributedSwitchList := ""
+			for _, sw := range dc.FindDistributedSwitches(host.Self) {
+				distributedSwitchList += sw.Name + "|"
+			}
+			distributedSwitchList = strings.TrimSuffix(distributedSwitchList, "|")
+			checkError(config.Logrus, ms.SetMetric("distributedSwitchNameList", distributedSwitchList, metric.ATTRIBUTE))
+
 			checkError(config.Logrus, ms.SetMetric("uuid", host.Summary.Hardware.Uuid, metric.ATTRIBUTE))
 
+			// product, hardware and hardening configuration
+			setHostConfigMetrics(config, ms, host)
+
 			// memory
 			memoryTotal := host.Summary.Hardware.MemorySize / (1 << 20)
 			checkError(config.Logrus, ms.SetMetric("mem.size", memoryTotal, metric.GAUGE))
@@ -150,6 +160,26 @@ func createHostSamples(config *config.Config) {
 			}
 			checkError(config.Logrus, ms.SetMetric("disk.totalMiB", diskTotalMiB, metric.GAUGE))
 
+			// vSAN
+			if config.VsanCollectionEnabled() {
+				setVsanHostMetrics(config, ms, dc, host)
+			}
+
+			// Hardware sensors
+			unhealthySensors := createHostSensorSamples(config, e, host)
+			checkError(config.Logrus, ms.SetMetric("sensors.unhealthy", unhealthySensors, metric.GAUGE))
+
+			// Physical nics
+			createPhysicalNicSamples(config, e, host)
+
+			// Host bus adapters and storage paths
+			createHostBusAdapterSamples(config, e, host)
+			deadPaths := createMultipathSamples(config, e, host)
+			checkError(config.Logrus, ms.SetMetric("storage.deadPaths", deadPaths, metric.GAUGE))
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, host.Self)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(host.Self)
@@ -165,6 +195,7 @@ func createHostSamples(config *config.Config) {
 				for _, perfMetric := range perfMetrics {
 					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
 				}
+				createPerfInstanceSamples(config, e, entityTypeHost, perfMetrics)
 			}
 
 		}
@@ -0,0 +1,109 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"math"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Sources of the host sensor samples
+const (
+	sensorSourceNumeric  = "numericSensor"
+	sensorSourceHardware = "hardwareStatus"
+)
+
+// Health states of the sensors reporting a problem, unknown states are not considered failures
+const (
+	sensorWarning  = "yellow"
+	sensorCritical = "red"
+)
+
+// createHostSensorSamples adds a sample for each hardware sensor and hardware element status of the host to the
+// host entity. It returns the number of sensors and elements whose health state is yellow or red.
+func createHostSensorSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem) (unhealthy int) {
+	healthRuntime := host.Runtime.HealthSystemRuntime
+	if healthRuntime == nil {
+		return 0
+	}
+
+	// numeric sensors include fans, power supplies, temperature and voltage readings
+	if healthRuntime.SystemHealthInfo != nil {
+		for _, sensor := range healthRuntime.SystemHealthInfo.NumericSensorInfo {
+			ms := e.NewMetricSet("VSphere" + sampleTypeSensorHost + "Sample")
+
+			checkError(config.Logrus, ms.SetMetric("source", sensorSourceNumeric, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("name", sensor.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("sensorType", sensor.SensorType, metric.ATTRIBUTE))
+			if sensor.Id != "" {
+				checkError(config.Logrus, ms.SetMetric("sensorId", sensor.Id, metric.ATTRIBUTE))
+			}
+			if !setSensorHealthState(config, ms, sensor.HealthState) {
+				unhealthy++
+			}
+
+			// readings are scaled by 10 to the power of the unit modifier
+			reading := float64(sensor.CurrentReading) * math.Pow10(int(sensor.UnitModifier))
+			checkError(config.Logrus, ms.SetMetric("reading", reading, metric.GAUGE))
+			if sensor.BaseUnits != "" {
+				checkError(config.Logrus, ms.SetMetric("units", sensor.BaseUnits, metric.ATTRIBUTE))
+			}
+			if sensor.RateUnits != "" {
+				checkError(config.Logrus, ms.SetMetric("rateUnits", sensor.RateUnits, metric.ATTRIBUTE))
+			}
+		}
+	}
+
+	// hardware elements status of memory, cpus and storage controllers
+	if status := healthRuntime.HardwareStatusInfo; status != nil {
+		var elements []*types.HostHardwareElementInfo
+		var sensorTypes []string
+		for _, element := range status.MemoryStatusInfo {
+			elements = append(elements, element.GetHostHardwareElementInfo())
+			sensorTypes = append(sensorTypes, "memory")
+		}
+		for _, element := range status.CpuStatusInfo {
+			elements = append(elements, element.GetHostHardwareElementInfo())
+			sensorTypes = append(sensorTypes, "cpu")
+		}
+		for i := range status.StorageStatusInfo {
+			elements = append(elements, &status.StorageStatusInfo[i].HostHardwareElementInfo)
+			sensorTypes = append(sensorTypes, "storage")
+		}
+
+		for i, element := range elements {
+			ms := e.NewMetricSet("VSphere" + sampleTypeSensorHost + "Sample")
+
+			checkError(config.Logrus, ms.SetMetric("source", sensorSourceHardware, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("name", element.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("sensorType", sensorTypes[i], metric.ATTRIBUTE))
+			if !setSensorHealthState(config, ms, element.Status) {
+				unhealthy++
+			}
+		}
+	}
+	return unhealthy
+}
+
+// setSensorHealthState adds the health state of a sensor to its sample returning whether it is healthy.
+// Sensors without a health state or in an unknown state are considered healthy.
+func setSensorHealthState(config *config.Config, ms *metric.Set, state types.BaseElementDescription) bool {
+	if state == nil {
+		return true
+	}
+	description := state.GetElementDescription()
+	// numeric sensors report lower case keys, while hardware elements report them capitalized
+	healthState := strings.ToLower(description.Key)
+	checkError(config.Logrus, ms.SetMetric("healthState", healthState, metric.ATTRIBUTE))
+	if description.Summary != "" {
+		checkError(config.Logrus, ms.SetMetric("healthSummary", description.Summary, metric.ATTRIBUTE))
+	}
+	return healthState != sensorWarning && healthState != sensorCritical
+}
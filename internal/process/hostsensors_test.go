@@ -0,0 +1,68 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createHostSensorSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		collect.Hosts(cfg)
+
+		// given a host with a failed power supply and a memory module in unknown state
+		var failedHost string
+		for _, host := range cfg.Datacenters[0].Hosts {
+			healthRuntime := host.Runtime.HealthSystemRuntime
+			require.NotNil(t, healthRuntime)
+			healthRuntime.SystemHealthInfo.NumericSensorInfo = append(healthRuntime.SystemHealthInfo.NumericSensorInfo, types.HostNumericSensorInfo{
+				Name:           "Power Supply 1",
+				HealthState:    &types.ElementDescription{Key: "red", Description: types.Description{Summary: "Sensor is operating under critical conditions"}},
+				CurrentReading: 1200,
+				UnitModifier:   -1,
+				BaseUnits:      "Watts",
+				SensorType:     "power",
+			})
+			healthRuntime.HardwareStatusInfo = &types.HostHardwareStatusInfo{
+				MemoryStatusInfo: []types.BaseHostHardwareElementInfo{
+					&types.HostHardwareElementInfo{Name: "DIMM 1", Status: &types.ElementDescription{Key: "Unknown"}},
+				},
+			}
+			failedHost = host.Summary.Config.Name
+			break
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		host := entityWith(cfg.Integration.Entities, "VSphereHostSample", "hypervisorHostname", failedHost)
+		require.NotNil(t, host)
+		samples := samplesByEventType(host)
+		require.Len(t, samples["VSphereHostSample"], 1)
+		assert.Equal(t, float64(1), samples["VSphereHostSample"][0]["sensors.unhealthy"])
+
+		psu := sampleWith(samples["VSphereSensorHostSample"], "name", "Power Supply 1")
+		dimm := sampleWith(samples["VSphereSensorHostSample"], "name", "DIMM 1")
+		require.NotNil(t, psu)
+		assert.Equal(t, "numericSensor", psu["source"])
+		assert.Equal(t, "power", psu["sensorType"])
+		assert.Equal(t, "red", psu["healthState"])
+		assert.Equal(t, float64(120), psu["reading"])
+		assert.Equal(t, "Watts", psu["units"])
+
+		require.NotNil(t, dimm)
+		assert.Equal(t, "hardwareStatus", dimm["source"])
+		assert.Equal(t, "memory", dimm["sensorType"])
+		assert.Equal(t, "unknown", dimm["healthState"])
+		return nil
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Multipath path states
+const (
+	pathStateActive   = "active"
+	pathStateStandby  = "standby"
+	pathStateDisabled = "disabled"
+	pathStateDead     = "dead"
+)
+
+// createHostBusAdapterSamples adds a sample for each host bus adapter of the host to the host entity
+func createHostBusAdapterSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem) {
+	if host.Config == nil || host.Config.StorageDevice == nil {
+		return
+	}
+
+	for _, adapter := range host.Config.StorageDevice.HostBusAdapter {
+		hba := adapter.GetHostHostBusAdapter()
+		ms := e.NewMetricSet("VSphere" + sampleTypeHbaHost + "Sample")
+
+		checkError(config.Logrus, ms.SetMetric("name", hba.Device, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("key", hba.Key, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("model", hba.Model, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("driver", hba.Driver, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("status", hba.Status, metric.ATTRIBUTE))
+		if hba.Pci != "" {
+			checkError(config.Logrus, ms.SetMetric("pci", hba.Pci, metric.ATTRIBUTE))
+		}
+		if hba.StorageProtocol != "" {
+			checkError(config.Logrus, ms.SetMetric("storageProtocol", hba.StorageProtocol, metric.ATTRIBUTE))
+		}
+
+		checkError(config.Logrus, ms.SetMetric("type", hostBusAdapterType(adapter), metric.ATTRIBUTE))
+		switch a := adapter.(type) {
+		case *types.HostFibreChannelOverEthernetHba:
+			checkError(config.Logrus, ms.SetMetric("portWorldWideName", worldWideName(a.PortWorldWideName), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("nodeWorldWideName", worldWideName(a.NodeWorldWideName), metric.ATTRIBUTE))
+		case *types.HostFibreChannelHba:
+			checkError(config.Logrus, ms.SetMetric("portWorldWideName", worldWideName(a.PortWorldWideName), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("nodeWorldWideName", worldWideName(a.NodeWorldWideName), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("portType", string(a.PortType), metric.ATTRIBUTE))
+		case *types.HostInternetScsiHba:
+			checkError(config.Logrus, ms.SetMetric("iScsiName", a.IScsiName, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("isSoftwareBased", fmt.Sprintf("%t", a.IsSoftwareBased), metric.ATTRIBUTE))
+		}
+	}
+}
+
+// createMultipathSamples adds a sample with the state of the paths of each logical unit of the host to the
+// host entity. It returns the number of dead paths of the host.
+func createMultipathSamples(config *config.Config, e *integration.Entity, host *mo.HostSystem) (deadPaths int) {
+	if host.Config == nil || host.Config.StorageDevice == nil || host.Config.StorageDevice.MultipathInfo == nil {
+		return 0
+	}
+	storage := host.Config.StorageDevice
+
+	luns := make(map[string]*types.ScsiLun)
+	for _, lun := range storage.ScsiLun {
+		l := lun.GetScsiLun()
+		luns[l.Key] = l
+	}
+
+	for _, lu := range storage.MultipathInfo.Lun {
+		ms := e.NewMetricSet("VSphere" + sampleTypeMultipathHost + "Sample")
+
+		checkError(config.Logrus, ms.SetMetric("id", lu.Id, metric.ATTRIBUTE))
+		if lun, ok := luns[lu.Lun]; ok {
+			checkError(config.Logrus, ms.SetMetric("canonicalName", lun.CanonicalName, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("displayName", lun.DisplayName, metric.ATTRIBUTE))
+		}
+		if lu.Policy != nil {
+			checkError(config.Logrus, ms.SetMetric("policy", lu.Policy.GetHostMultipathInfoLogicalUnitPolicy().Policy, metric.ATTRIBUTE))
+		}
+		if lu.StorageArrayTypePolicy != nil {
+			checkError(config.Logrus, ms.SetMetric("storageArrayTypePolicy", lu.StorageArrayTypePolicy.Policy, metric.ATTRIBUTE))
+		}
+
+		pathStates := make(map[string]int)
+		for _, path := range lu.Path {
+			pathStates[path.PathState]++
+		}
+		checkError(config.Logrus, ms.SetMetric("pathCount", len(lu.Path), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("activePaths", pathStates[pathStateActive], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("standbyPaths", pathStates[pathStateStandby], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("disabledPaths", pathStates[pathStateDisabled], metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("deadPaths", pathStates[pathStateDead], metric.GAUGE))
+		deadPaths += pathStates[pathStateDead]
+	}
+	return deadPaths
+}
+
+// hostBusAdapterType returns the type of the host bus adapter, such as fibreChannel or iScsi
+func hostBusAdapterType(adapter types.BaseHostHostBusAdapter) string {
+	switch adapter.(type) {
+	case *types.HostFibreChannelOverEthernetHba:
+		return "fibreChannelOverEthernet"
+	case *types.HostFibreChannelHba:
+		return "fibreChannel"
+	case *types.HostInternetScsiHba:
+		return "iScsi"
+	case *types.HostParallelScsiHba:
+		return "parallelScsi"
+	case *types.HostBlockHba:
+		return "block"
+	case *types.HostSerialAttachedHba:
+		return "serialAttached"
+	case *types.HostPcieHba:
+		return "pcie"
+	case *types.HostRdmaHba:
+		return "rdma"
+	case *types.HostTcpHba:
+		return "tcp"
+	}
+	return "unknown"
+}
+
+// worldWideName formats a fibre channel world wide name as colon separated bytes, such as 20:00:00:25:b5:00:00:0f
+func worldWideName(wwn int64) string {
+	hex := fmt.Sprintf("%016x", uint64(wwn))
+	var bytes []string
+	for i := 0; i < len(hex); i += 2 {
+		bytes = append(bytes, hex[i:i+2])
+	}
+	return strings.Join(bytes, ":")
+}
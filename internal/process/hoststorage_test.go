@@ -0,0 +1,70 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createHostDeviceSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		collect.Hosts(cfg)
+
+		// given a host with a fibre channel adapter and a dead path
+		var hostName string
+		for _, host := range cfg.Datacenters[0].Hosts {
+			storage := host.Config.StorageDevice
+			storage.HostBusAdapter = append(storage.HostBusAdapter, &types.HostFibreChannelHba{
+				HostHostBusAdapter: types.HostHostBusAdapter{Device: "vmhba2", Status: "online", Model: "FC HBA"},
+				PortWorldWideName:  0x20000025b500000f,
+				NodeWorldWideName:  0x20000025b500010f,
+			})
+			lu := &storage.MultipathInfo.Lun[0]
+			lu.Path = append(lu.Path, types.HostMultipathInfoPath{Name: "vmhba2:C0:T0:L0", PathState: "dead"})
+			hostName = host.Summary.Config.Name
+			break
+		}
+
+		// when
+		createHostSamples(cfg)
+
+		// then
+		host := entityWith(cfg.Integration.Entities, "VSphereHostSample", "hypervisorHostname", hostName)
+		require.NotNil(t, host)
+		samples := samplesByEventType(host)
+
+		require.Len(t, samples["VSphereHostSample"], 1)
+		assert.Equal(t, float64(1), samples["VSphereHostSample"][0]["storage.deadPaths"])
+
+		require.NotEmpty(t, samples["VSpherePnicHostSample"])
+		pnic := samples["VSpherePnicHostSample"][0]
+		assert.Equal(t, "vmnic0", pnic["name"])
+		assert.Equal(t, "true", pnic["linkUp"])
+		assert.Contains(t, pnic, "linkSpeedMb")
+		assert.Contains(t, pnic, "duplex")
+		assert.Equal(t, "vSwitch0", pnic["virtualSwitchName"])
+
+		fc := sampleWith(samples["VSphereHbaHostSample"], "name", "vmhba2")
+		require.NotNil(t, fc)
+		assert.Equal(t, "fibreChannel", fc["type"])
+		assert.Equal(t, "online", fc["status"])
+		assert.Equal(t, "20:00:00:25:b5:00:00:0f", fc["portWorldWideName"])
+		assert.Equal(t, "20:00:00:25:b5:00:01:0f", fc["nodeWorldWideName"])
+
+		multipath := sampleWith(samples["VSphereMultipathHostSample"], "deadPaths", float64(1))
+		require.NotNil(t, multipath)
+		assert.Equal(t, float64(2), multipath["pathCount"])
+		assert.Equal(t, float64(1), multipath["activePaths"])
+		assert.Equal(t, "VMW_PSP_FIXED", multipath["policy"])
+		assert.Contains(t, multipath, "canonicalName")
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+)
+
+// distributedPortgroupType is the type of the networks reported as VSphereDistributedPortgroupSample
+const distributedPortgroupType = "DistributedVirtualPortgroup"
+
+func createNetworkSamples(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		for _, nw := range dc.Networks {
+
+			if nw.Self.Type == distributedPortgroupType {
+				continue
+			}
+
+			// filtering here will to avoid sending data to backend
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(nw.Self) {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+
+			entityName := sanitizeEntityName(config, nw.Name, datacenterName)
+
+			networkID := morefIdentifier(config, nw.Self)
+
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeNetwork, entityName, networkID)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("networkName", entityName).WithField("networkID", networkID).Error("failed to create metricSet")
+				continue
+			}
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			checkError(config.Logrus, ms.SetMetric("name", nw.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("overallStatus", string(nw.OverallStatus), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("vmCount", len(nw.Vm), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("hostCount", len(nw.Host), metric.GAUGE))
+
+			if nw.Summary != nil {
+				summary := nw.Summary.GetNetworkSummary()
+				checkError(config.Logrus, ms.SetMetric("accessible", fmt.Sprintf("%t", summary.Accessible), metric.ATTRIBUTE))
+				if summary.IpPoolName != "" {
+					checkError(config.Logrus, ms.SetMetric("ipPoolName", summary.IpPoolName, metric.ATTRIBUTE))
+				}
+				if summary.IpPoolId != nil {
+					checkError(config.Logrus, ms.SetMetric("ipPoolId", *summary.IpPoolId, metric.ATTRIBUTE))
+				}
+			}
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, nw.Self)
+
+			// Tags
+			if config.TagCollectionEnabled() {
+				tagsByCategory := config.TagCollector.GetTagsByCategories(nw.Self)
+				for k, v := range tagsByCategory {
+					checkError(config.Logrus, ms.SetMetric(tagsPrefix+k, v, metric.ATTRIBUTE))
+					// add tags to inventory due to the inventory workaround
+					addTagsToInventory(config, e, k, v)
+				}
+			}
+		}
+	}
+}
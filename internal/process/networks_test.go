@@ -0,0 +1,43 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_createNetworkSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+		cfg.Args.DatacenterLocation = "east"
+
+		// when
+		collect.Networks(cfg)
+		createNetworkSamples(cfg)
+
+		// then distributed portgroups are not reported as networks
+		require.Len(t, cfg.Integration.Entities, 1)
+		e := cfg.Integration.Entities[0]
+		assert.Equal(t, "vsphere-network", e.Metadata.Namespace)
+		// networks are identified by the vCenter and their managed object reference, since names are not unique
+		for ref := range cfg.Datacenters[0].Networks {
+			if ref.Type != distributedPortgroupType {
+				assert.Equal(t, "east:"+vc.ServiceContent.About.InstanceUuid+":"+ref.Value, e.Metadata.Name)
+			}
+		}
+		require.Len(t, e.Metrics, 1)
+		ms := e.Metrics[0].Metrics
+		assert.Equal(t, "VSphereNetworkSample", ms["event_type"])
+		assert.Equal(t, "VM Network", ms["name"])
+		assert.Equal(t, "true", ms["accessible"])
+		assert.Contains(t, ms, "hostCount")
+		assert.Contains(t, ms, "vmCount")
+		return nil
+	})
+}
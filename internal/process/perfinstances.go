@@ -0,0 +1,35 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"sort"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+)
+
+// createPerfInstanceSamples adds a sample for each instance of the performance counters of the entity, such as a cpu
+// core or a vmnic, since the perf metrics of the entity sample aggregate the values of all of them
+func createPerfInstanceSamples(config *config.Config, e *integration.Entity, entityType string, perfMetrics []performance.PerfMetric) {
+	if !config.PerfInstanceSamplesEnabled() {
+		return
+	}
+	for _, perfMetric := range perfMetrics {
+		instances := make([]string, 0, len(perfMetric.Instances))
+		for instance := range perfMetric.Instances {
+			instances = append(instances, instance)
+		}
+		sort.Strings(instances)
+
+		for _, instance := range instances {
+			ms := e.NewMetricSet("VSphere" + entityType + sampleTypePerfInstance + "Sample")
+			checkError(config.Logrus, ms.SetMetric("counter", perfMetric.Counter, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("instance", instance, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("value", perfMetric.Instances[instance], metric.GAUGE))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createPerfInstanceSamples(t *testing.T) {
+	perfMetrics := []performance.PerfMetric{
+		{Counter: "net.usage.average", Value: 500, Instances: map[string]int64{"vmnic1": 900, "vmnic0": 100}},
+		{Counter: "mem.usage.average", Value: 30},
+	}
+
+	cfg := &config.Config{
+		Logrus: logrus.StandardLogger(),
+		Args:   config.ArgumentList{EnableVspherePerfMetrics: true},
+	}
+	i, err := integration.New("test", "dev")
+	require.NoError(t, err)
+	e, err := i.Entity("host", "vsphere-host")
+	require.NoError(t, err)
+
+	// no samples unless enabled
+	createPerfInstanceSamples(cfg, e, entityTypeHost, perfMetrics)
+	assert.Len(t, e.Metrics, 0)
+
+	cfg.Args.EnablePerfInstanceSamples = true
+	createPerfInstanceSamples(cfg, e, entityTypeHost, perfMetrics)
+
+	require.Len(t, e.Metrics, 2)
+	for n, instance := range []string{"vmnic0", "vmnic1"} {
+		sample := e.Metrics[n].Metrics
+		assert.Equal(t, "VSphereHostPerfInstanceSample", sample["event_type"])
+		assert.Equal(t, "net.usage.average", sample["counter"])
+		assert.Equal(t, instance, sample["instance"])
+	}
+	assert.Equal(t, float64(100), e.Metrics[0].Metrics["value"])
+	assert.Equal(t, float64(900), e.Metrics[1].Metrics["value"])
+}
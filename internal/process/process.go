@@ -4,6 +4,7 @@
 package process
 
 import (
+	"net/url"
 	"strings"
 	"sync"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/newrelic/nri-vsphere/internal/config"
 
 	logrus "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 const (
@@ -21,9 +23,33 @@ const (
 	entityTypeResourcePool = "ResourcePool"
 	entityTypeVm           = "Vm"
 	entityTypeDatastore    = "Datastore"
+	entityTypeNetwork      = "Network"
+
+	entityTypeDatastoreCluster = "DatastoreCluster"
+
+	entityTypeDistributedSwitch    = "DistributedSwitch"
+	entityTypeDistributedPortgroup = "DistributedPortgroup"
 	//The sampleTypeSnapshotVm is used to create a sample, however it does not have a corresponding entity
 	//sampleTypeSnapshotVm is attached to a vm entity.
 	sampleTypeSnapshotVm = "SnapshotVm"
+	//The sampleTypeDiskVm is used to create a sample for each virtual disk attached to a vm entity.
+	sampleTypeDiskVm = "DiskVm"
+	//The sampleTypeNicVm is used to create a sample for each virtual nic attached to a vm entity.
+	sampleTypeNicVm = "NicVm"
+	//The sampleTypeGuestFilesystemVm is used to create a sample for each guest filesystem of a vm entity.
+	sampleTypeGuestFilesystemVm = "GuestFilesystemVm"
+	//The sampleTypeSensorHost is used to create a sample for each hardware sensor of a host entity.
+	sampleTypeSensorHost = "SensorHost"
+	//The sampleTypePnicHost is used to create a sample for each physical nic of a host entity.
+	sampleTypePnicHost = "PnicHost"
+	//The sampleTypeHbaHost is used to create a sample for each host bus adapter of a host entity.
+	sampleTypeHbaHost = "HbaHost"
+	//The sampleTypeMultipathHost is used to create a sample for each storage logical unit paths of a host entity.
+	sampleTypeMultipathHost = "MultipathHost"
+	//The sampleTypeTriggeredAlarm is used to create a sample for each alarm triggered on any entity.
+	sampleTypeTriggeredAlarm = "TriggeredAlarm"
+	//The sampleTypePerfInstance is used to create a sample for each instance of the performance counters of an entity.
+	sampleTypePerfInstance = "PerfInstance"
 
 	tagsPrefix       = "label."
 	tagsInventoryKey = "tags"
@@ -32,9 +58,19 @@ const (
 
 // Run process samples
 func ProcessData(config *config.Config) {
+	ProcessSamples(config)
+	// events are attached to the entities created above
+	ProcessEvents(config)
+}
+
+// ProcessSamples creates the samples of every object collected
+func ProcessSamples(config *config.Config) {
+	// entities of the previous run have already been published
+	config.ResetEntities()
+
 	// create samples async
 	var wg sync.WaitGroup
-	wg.Add(6)
+	wg.Add(10)
 	go func() {
 		defer wg.Done()
 		createVirtualMachineSamples(config)
@@ -59,9 +95,30 @@ func ProcessData(config *config.Config) {
 		defer wg.Done()
 		createResourcePoolSamples(config)
 	}()
+	go func() {
+		defer wg.Done()
+		createNetworkSamples(config)
+	}()
+	go func() {
+		defer wg.Done()
+		createDatastoreClusterSamples(config)
+	}()
+	go func() {
+		defer wg.Done()
+		createDistributedSwitchSamples(config)
+	}()
+	go func() {
+		defer wg.Done()
+		createDistributedPortgroupSamples(config)
+	}()
 	wg.Wait()
 }
 
+// ProcessEvents adds the events and tasks collected to the entities reported by the last call to ProcessSamples
+func ProcessEvents(config *config.Config) {
+	createEventSamples(config)
+}
+
 // determineOS perform best effor to determine the operatingSystem
 func determineOS(guestFullName string) string {
 
@@ -121,12 +178,35 @@ func sanitizeEntityName(config *config.Config, entityName string, datacenterName
 	return entityName
 }
 
+// morefIdentifier returns the unique identifier of the objects with no uuid, whose names are only unique within
+// their folder: the managed object reference, only unique within the endpoint, prefixed by the endpoint instance so
+// the objects of different vCenters never collide, and by the datacenter location if set
+func morefIdentifier(config *config.Config, ref types.ManagedObjectReference) string {
+	id := endpointInstance(config) + ":" + ref.Value
+	if config.Args.DatacenterLocation != "" {
+		return config.Args.DatacenterLocation + ":" + id
+	}
+	return id
+}
+
+// endpointInstance returns the instance uuid of the vCenter, or the host of the url of ESXi endpoints which have none
+func endpointInstance(config *config.Config) string {
+	if config.VMWareClient != nil && config.VMWareClient.ServiceContent.About.InstanceUuid != "" {
+		return config.VMWareClient.ServiceContent.About.InstanceUuid
+	}
+	if u, err := url.Parse(config.Args.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return config.Args.URL
+}
+
 func createNewEntityWithMetricSet(config *config.Config, typeEntity string, entityName string, uniqueIdentifier string) (*integration.Entity, *metric.Set, error) {
 	workingEntity, err := config.Integration.Entity(uniqueIdentifier, "vsphere-"+strings.ToLower(typeEntity))
 	if err != nil {
 		config.Logrus.WithError(err).Error("failed to create entity")
 		return nil, nil, err
 	}
+	config.AddEntity(entityKey(typeEntity, uniqueIdentifier))
 
 	// entity displayName
 	if config.Args.HasInventory() {
@@ -0,0 +1,78 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/client"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// newSimulatorConfig returns the configuration of a vCenter connected to the simulator, with its first datacenter
+func newSimulatorConfig(ctx context.Context, t *testing.T, vc *vim25.Client) *config.Config {
+	vmClient, err := client.New(vc.URL().String(), "user", "pass", false)
+	require.NoError(t, err)
+	vm := view.NewManager(vc)
+
+	cfg := &config.Config{VMWareClient: vmClient, ViewManager: vm, Logrus: logrus.StandardLogger(), IsVcenterAPIType: true}
+	cfg.Integration, err = integration.New("test", "dev")
+	require.NoError(t, err)
+	cfg.Datacenters = append(cfg.Datacenters, getDatacenter(ctx, vm))
+	return cfg
+}
+
+// samplesByEventType returns the metrics of the samples of the entities by event type
+func samplesByEventType(entities ...*integration.Entity) map[string][]map[string]interface{} {
+	samples := map[string][]map[string]interface{}{}
+	for _, e := range entities {
+		for _, ms := range e.Metrics {
+			eventType, _ := ms.Metrics["event_type"].(string)
+			samples[eventType] = append(samples[eventType], ms.Metrics)
+		}
+	}
+	return samples
+}
+
+// sampleWith returns the first sample with the value of the metric, nil if none
+func sampleWith(samples []map[string]interface{}, name string, value interface{}) map[string]interface{} {
+	for _, sample := range samples {
+		if sample[name] == value {
+			return sample
+		}
+	}
+	return nil
+}
+
+// entityWith returns the first entity with a sample of the event type with the value of the metric, nil if none
+func entityWith(entities []*integration.Entity, eventType string, name string, value interface{}) *integration.Entity {
+	for _, e := range entities {
+		if sampleWith(samplesByEventType(e)[eventType], name, value) != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func Test_morefIdentifier(t *testing.T) {
+	ref := types.ManagedObjectReference{Type: "Network", Value: "network-7"}
+
+	vcenter := &config.Config{VMWareClient: &govmomi.Client{Client: &vim25.Client{}}}
+	vcenter.VMWareClient.ServiceContent.About.InstanceUuid = "vc-uuid"
+	assert.Equal(t, "vc-uuid:network-7", morefIdentifier(vcenter, ref))
+
+	vcenter.Args.DatacenterLocation = "east"
+	assert.Equal(t, "east:vc-uuid:network-7", morefIdentifier(vcenter, ref))
+
+	// ESXi endpoints have no instance uuid
+	esxi := &config.Config{VMWareClient: &govmomi.Client{Client: &vim25.Client{}}}
+	esxi.Args.URL = "https://esxi-1.example.com/sdk"
+	assert.Equal(t, "esxi-1.example.com:network-7", morefIdentifier(esxi, ref))
+}
@@ -74,6 +74,9 @@ func createResourcePoolSamples(config *config.Config) {
 
 			checkError(config.Logrus, ms.SetMetric("overallStatus", string(rp.OverallStatus), metric.ATTRIBUTE))
 
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, rp.Self)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(rp.Self)
@@ -89,6 +92,7 @@ func createResourcePoolSamples(config *config.Config) {
 				for _, perfMetric := range perfMetrics {
 					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
 				}
+				createPerfInstanceSamples(config, e, entityTypeResourcePool, perfMetrics)
 			}
 		}
 	}
@@ -0,0 +1,115 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/nri-vsphere/internal/config"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+)
+
+func createDatastoreClusterSamples(config *config.Config) {
+	for _, dc := range config.Datacenters {
+		for _, pod := range dc.StoragePods {
+
+			// filtering here will to avoid sending data to backend
+			if config.TagFilteringEnabled() && !config.TagCollector.MatchObjectTags(pod.Self) {
+				continue
+			}
+
+			datacenterName := dc.Datacenter.Name
+
+			entityName := sanitizeEntityName(config, pod.Name, datacenterName)
+
+			datastoreClusterID := morefIdentifier(config, pod.Self)
+
+			e, ms, err := createNewEntityWithMetricSet(config, entityTypeDatastoreCluster, entityName, datastoreClusterID)
+			if err != nil {
+				config.Logrus.WithError(err).WithField("datastoreClusterName", entityName).WithField("datastoreClusterID", datastoreClusterID).Error("failed to create metricSet")
+				continue
+			}
+
+			if config.Args.DatacenterLocation != "" {
+				checkError(config.Logrus, ms.SetMetric("datacenterLocation", config.Args.DatacenterLocation, metric.ATTRIBUTE))
+			}
+
+			if config.IsVcenterAPIType {
+				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
+			}
+
+			checkError(config.Logrus, ms.SetMetric("name", pod.Name, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("overallStatus", string(pod.OverallStatus), metric.ATTRIBUTE))
+
+			if pod.Summary != nil {
+				capacity := float64(pod.Summary.Capacity) / (1 << 30)
+				freeSpace := float64(pod.Summary.FreeSpace) / (1 << 30)
+				checkError(config.Logrus, ms.SetMetric("capacity", capacity, metric.GAUGE))
+				checkError(config.Logrus, ms.SetMetric("freeSpace", freeSpace, metric.GAUGE))
+				if capacity > 0 {
+					checkError(config.Logrus, ms.SetMetric("usedPercent", (capacity-freeSpace)/capacity*100, metric.GAUGE))
+				}
+			}
+
+			datastoreList := ""
+			for _, child := range pod.ChildEntity {
+				if ds, ok := dc.Datastores[child]; ok {
+					datastoreList += ds.Name + "|"
+				}
+			}
+			datastoreList = strings.TrimSuffix(datastoreList, "|")
+			checkError(config.Logrus, ms.SetMetric("datastoreCount", len(pod.ChildEntity), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric("datastoreNameList", datastoreList, metric.ATTRIBUTE))
+
+			// Storage DRS
+			if entry := pod.PodStorageDrsEntry; entry != nil {
+				podConfig := entry.StorageDrsConfig.PodConfig
+				checkError(config.Logrus, ms.SetMetric("sdrs.enabled", fmt.Sprintf("%t", podConfig.Enabled), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("sdrs.automationLevel", podConfig.DefaultVmBehavior, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("sdrs.ioLoadBalanceEnabled", fmt.Sprintf("%t", podConfig.IoLoadBalanceEnabled), metric.ATTRIBUTE))
+
+				if space := podConfig.SpaceLoadBalanceConfig; space != nil {
+					if space.SpaceThresholdMode != "" {
+						checkError(config.Logrus, ms.SetMetric("sdrs.spaceThresholdMode", space.SpaceThresholdMode, metric.ATTRIBUTE))
+					}
+					if space.SpaceUtilizationThreshold != 0 {
+						checkError(config.Logrus, ms.SetMetric("sdrs.spaceUtilizationThreshold", int(space.SpaceUtilizationThreshold), metric.GAUGE))
+					}
+					if space.FreeSpaceThresholdGB != 0 {
+						checkError(config.Logrus, ms.SetMetric("sdrs.freeSpaceThresholdGB", int(space.FreeSpaceThresholdGB), metric.GAUGE))
+					}
+					if space.MinSpaceUtilizationDifference != 0 {
+						checkError(config.Logrus, ms.SetMetric("sdrs.minSpaceUtilizationDifference", int(space.MinSpaceUtilizationDifference), metric.GAUGE))
+					}
+				}
+
+				if io := podConfig.IoLoadBalanceConfig; io != nil {
+					if io.IoLatencyThreshold != 0 {
+						checkError(config.Logrus, ms.SetMetric("sdrs.ioLatencyThreshold", int(io.IoLatencyThreshold), metric.GAUGE))
+					}
+					if io.IoLoadImbalanceThreshold != 0 {
+						checkError(config.Logrus, ms.SetMetric("sdrs.ioLoadImbalanceThreshold", int(io.IoLoadImbalanceThreshold), metric.GAUGE))
+					}
+				}
+
+				checkError(config.Logrus, ms.SetMetric("sdrs.pendingRecommendations", len(entry.Recommendation), metric.GAUGE))
+			}
+
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, pod.Self)
+
+			// Tags
+			if config.TagCollectionEnabled() {
+				tagsByCategory := config.TagCollector.GetTagsByCategories(pod.Self)
+				for k, v := range tagsByCategory {
+					checkError(config.Logrus, ms.SetMetric(tagsPrefix+k, v, metric.ATTRIBUTE))
+					// add tags to inventory due to the inventory workaround
+					addTagsToInventory(config, e, k, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_createDatastoreClusterSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given a datastore cluster with a datastore
+		finder := find.NewFinder(vc)
+		dc, err := finder.DefaultDatacenter(ctx)
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+		folders, err := dc.Folders(ctx)
+		require.NoError(t, err)
+		pod, err := folders.DatastoreFolder.CreateStoragePod(ctx, "DSC0")
+		require.NoError(t, err)
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+		task, err := pod.MoveInto(ctx, []types.ManagedObjectReference{ds.Reference()})
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		cfg := newSimulatorConfig(ctx, t, vc)
+		cfg.Args.DatacenterLocation = "east"
+
+		// when
+		collect.Datastores(cfg)
+		collect.StoragePods(cfg)
+
+		createDatastoreClusterSamples(cfg)
+		createDatastoreSamples(cfg)
+
+		// then datastore clusters are identified by the vCenter and their managed object reference, since names are not unique
+		assert.True(t, cfg.HasEntity(entityKey(entityTypeDatastoreCluster, "east:"+vc.ServiceContent.About.InstanceUuid+":"+pod.Reference().Value)))
+
+		samples := samplesByEventType(cfg.Integration.Entities...)
+		require.Len(t, samples["VSphereDatastoreClusterSample"], 1)
+		dsc := samples["VSphereDatastoreClusterSample"][0]
+		assert.Equal(t, "DSC0", dsc["name"])
+		assert.Equal(t, "LocalDS_0", dsc["datastoreNameList"])
+		assert.Equal(t, float64(1), dsc["datastoreCount"])
+		assert.Contains(t, dsc, "capacity")
+		assert.Contains(t, dsc, "freeSpace")
+
+		for _, ds := range samples["VSphereDatastoreSample"] {
+			if ds["name"] == "LocalDS_0" {
+				assert.Equal(t, "DSC0", ds["datastoreClusterName"])
+			} else {
+				assert.NotContains(t, ds, "datastoreClusterName")
+			}
+		}
+		return nil
+	})
+}
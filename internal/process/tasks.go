@@ -0,0 +1,112 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	eventSDK "github.com/newrelic/infra-integrations-sdk/v3/data/event"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/events"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// processTasks adds an event for each task collected, either completed since the last collection or in progress
+// with a new state, to the entity of the object the task applies to
+func processTasks(config *config.Config, td *events.TaskDispacher, router *entityRouter) error {
+	if td == nil {
+		return fmt.Errorf("not expecting empty TaskDispacher")
+	}
+	now := time.Now()
+	for _, info := range td.Tasks {
+		entity := router.entityFor(info.Entity)
+		if entity == nil {
+			continue
+		}
+		err := entity.AddEvent(newTaskEvent(info, now))
+		if err != nil {
+			config.Logrus.WithError(err).WithField("task", info.Key).Error("failed to add task event")
+		}
+	}
+	return nil
+}
+
+// newTaskEvent returns the event reporting the task, whose duration is computed until now if still running
+func newTaskEvent(info types.TaskInfo, now time.Time) *eventSDK.Event {
+	operation := info.DescriptionId
+	if info.Name != "" {
+		operation = info.Name
+	}
+
+	ev := &eventSDK.Event{
+		Summary:  strings.TrimSpace(fmt.Sprintf("Task %s %s %s", operation, info.EntityName, info.State)),
+		Category: "vSphereTask",
+		Attributes: map[string]interface{}{
+			"vSphereTask.key":           info.Key,
+			"vSphereTask.descriptionId": info.DescriptionId,
+			"vSphereTask.state":         string(info.State),
+			"vSphereTask.cancelled":     info.Cancelled,
+			"vSphereTask.queueTime":     info.QueueTime.Format(time.RFC1123),
+			"timestamp":                 taskTimestamp(info).Unix(),
+		},
+	}
+	if info.Name != "" {
+		ev.Attributes["vSphereTask.name"] = info.Name
+	}
+	if info.Progress != 0 {
+		ev.Attributes["vSphereTask.progress"] = int(info.Progress)
+	} else if info.State == types.TaskInfoStateSuccess {
+		ev.Attributes["vSphereTask.progress"] = 100
+	}
+	if info.Entity != nil {
+		ev.Attributes["vSphereTask.entityType"] = info.Entity.Type
+		ev.Attributes["vSphereTask.entityName"] = info.EntityName
+	}
+	if info.StartTime != nil {
+		ev.Attributes["vSphereTask.startTime"] = info.StartTime.Format(time.RFC1123)
+		end := now
+		if info.CompleteTime != nil {
+			end = *info.CompleteTime
+		}
+		ev.Attributes["vSphereTask.durationSeconds"] = end.Sub(*info.StartTime).Seconds()
+	}
+	if info.CompleteTime != nil {
+		ev.Attributes["vSphereTask.completeTime"] = info.CompleteTime.Format(time.RFC1123)
+	}
+	if info.Error != nil {
+		ev.Attributes["vSphereTask.error"] = info.Error.LocalizedMessage
+	}
+	if info.ParentTaskKey != "" {
+		ev.Attributes["vSphereTask.parentTaskKey"] = info.ParentTaskKey
+	}
+
+	// the reason holds who or what initiated the task
+	switch reason := info.Reason.(type) {
+	case *types.TaskReasonUser:
+		ev.Attributes["vSphereTask.reason"] = "user"
+		ev.Attributes["vSphereTask.userName"] = reason.UserName
+	case *types.TaskReasonSchedule:
+		ev.Attributes["vSphereTask.reason"] = "schedule"
+		ev.Attributes["vSphereTask.scheduledTaskName"] = reason.Name
+	case *types.TaskReasonAlarm:
+		ev.Attributes["vSphereTask.reason"] = "alarm"
+		ev.Attributes["vSphereTask.alarmName"] = reason.AlarmName
+	case *types.TaskReasonSystem:
+		ev.Attributes["vSphereTask.reason"] = "system"
+	}
+	return ev
+}
+
+// taskTimestamp returns the time of the last change of state of the task
+func taskTimestamp(info types.TaskInfo) time.Time {
+	if info.CompleteTime != nil {
+		return *info.CompleteTime
+	}
+	if info.StartTime != nil {
+		return *info.StartTime
+	}
+	return info.QueueTime
+}
@@ -0,0 +1,63 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newTaskEvent(t *testing.T) {
+	now := time.Now()
+	queued := now.Add(-10 * time.Minute)
+	started := now.Add(-9 * time.Minute)
+	completed := now.Add(-4 * time.Minute)
+
+	// given a vMotion failed after 5 minutes
+	failed := types.TaskInfo{
+		Key:           "task-1",
+		DescriptionId: "VirtualMachine.migrate",
+		Entity:        &types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+		EntityName:    "vm-1",
+		State:         types.TaskInfoStateError,
+		Error:         &types.LocalizedMethodFault{LocalizedMessage: "Migration failed"},
+		Reason:        &types.TaskReasonUser{UserName: "VSPHERE.LOCAL\\admin"},
+		QueueTime:     queued,
+		StartTime:     &started,
+		CompleteTime:  &completed,
+	}
+
+	ev := newTaskEvent(failed, now)
+
+	assert.Equal(t, "vSphereTask", ev.Category)
+	assert.Equal(t, "Task VirtualMachine.migrate vm-1 error", ev.Summary)
+	assert.Equal(t, "error", ev.Attributes["vSphereTask.state"])
+	assert.Equal(t, "Migration failed", ev.Attributes["vSphereTask.error"])
+	assert.Equal(t, "user", ev.Attributes["vSphereTask.reason"])
+	assert.Equal(t, "VSPHERE.LOCAL\\admin", ev.Attributes["vSphereTask.userName"])
+	assert.Equal(t, "VirtualMachine", ev.Attributes["vSphereTask.entityType"])
+	assert.Equal(t, "vm-1", ev.Attributes["vSphereTask.entityName"])
+	assert.Equal(t, float64(300), ev.Attributes["vSphereTask.durationSeconds"])
+	assert.Equal(t, completed.Unix(), ev.Attributes["timestamp"])
+
+	// given a clone still running
+	running := types.TaskInfo{
+		Key:           "task-2",
+		DescriptionId: "VirtualMachine.clone",
+		State:         types.TaskInfoStateRunning,
+		Progress:      40,
+		Reason:        &types.TaskReasonSystem{},
+		QueueTime:     queued,
+		StartTime:     &started,
+	}
+
+	ev = newTaskEvent(running, now)
+
+	assert.Equal(t, "running", ev.Attributes["vSphereTask.state"])
+	assert.Equal(t, 40, ev.Attributes["vSphereTask.progress"])
+	assert.Equal(t, "system", ev.Attributes["vSphereTask.reason"])
+	assert.Equal(t, float64(540), ev.Attributes["vSphereTask.durationSeconds"])
+	assert.NotContains(t, ev.Attributes, "vSphereTask.completeTime")
+	assert.NotContains(t, ev.Attributes, "vSphereTask.error")
+}
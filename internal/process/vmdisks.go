@@ -0,0 +1,130 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// virtualDiskPerfGroup is the group of the perf counters reported for each virtual disk instance
+const virtualDiskPerfGroup = "virtualDisk."
+
+// createVirtualDiskSamples adds a sample for each virtual disk of the vm to the vm entity
+func createVirtualDiskSamples(config *config.Config, e *integration.Entity, dc *model.Datacenter, vm *mo.VirtualMachine) {
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+
+	var perfMetrics []performance.PerfMetric
+	if config.PerfMetricsCollectionEnabled() {
+		perfMetrics = dc.GetPerfMetrics(vm.Self)
+	}
+
+	for _, device := range devices {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+		ms := e.NewMetricSet("VSphere" + sampleTypeDiskVm + "Sample")
+
+		if description := disk.GetVirtualDevice().DeviceInfo; description != nil {
+			checkError(config.Logrus, ms.SetMetric("name", description.GetDescription().Label, metric.ATTRIBUTE))
+		}
+		checkError(config.Logrus, ms.SetMetric("key", fmt.Sprintf("%d", disk.Key), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("capacityMiB", disk.CapacityInBytes/(1<<20), metric.GAUGE))
+
+		provisioningType, diskMode, sharing := virtualDiskBacking(disk.Backing)
+		checkError(config.Logrus, ms.SetMetric("provisioningType", provisioningType, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("diskMode", diskMode, metric.ATTRIBUTE))
+		if sharing != "" {
+			checkError(config.Logrus, ms.SetMetric("sharing", sharing, metric.ATTRIBUTE))
+		}
+
+		if backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+			fileBacking := backing.GetVirtualDeviceFileBackingInfo()
+			checkError(config.Logrus, ms.SetMetric("fileName", fileBacking.FileName, metric.ATTRIBUTE))
+			if fileBacking.Datastore != nil {
+				if ds, ok := dc.Datastores[*fileBacking.Datastore]; ok {
+					checkError(config.Logrus, ms.SetMetric("datastoreName", ds.Name, metric.ATTRIBUTE))
+				}
+			}
+		}
+
+		// the instance is the name of the controller bus and the unit number, such as scsi0:1
+		var instance string
+		if controller := devices.FindByKey(disk.ControllerKey); controller != nil {
+			if c, ok := controller.(types.BaseVirtualController); ok && disk.UnitNumber != nil {
+				busNumber := c.GetVirtualController().BusNumber
+				instance = fmt.Sprintf("%s%d:%d", controllerBus(controller), busNumber, *disk.UnitNumber)
+				checkError(config.Logrus, ms.SetMetric("controllerType", devices.Type(controller), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("busNumber", fmt.Sprintf("%d", busNumber), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("unitNumber", fmt.Sprintf("%d", *disk.UnitNumber), metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("instance", instance, metric.ATTRIBUTE))
+			}
+		}
+
+		if policy, ok := dc.VirtualDiskStoragePolicies[vm.Self][disk.Key]; ok {
+			checkError(config.Logrus, ms.SetMetric("storagePolicy", policy, metric.ATTRIBUTE))
+		}
+
+		// Performance metrics of the disk instance
+		if instance == "" {
+			continue
+		}
+		for _, perfMetric := range perfMetrics {
+			if !strings.HasPrefix(perfMetric.Counter, virtualDiskPerfGroup) {
+				continue
+			}
+			if value, ok := perfMetric.Instances[instance]; ok {
+				checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, value, metric.GAUGE))
+			}
+		}
+	}
+}
+
+// virtualDiskBacking returns the provisioning type, the disk mode and the sharing mode of the disk backing
+func virtualDiskBacking(backing types.BaseVirtualDeviceBackingInfo) (provisioningType string, diskMode string, sharing string) {
+	switch b := backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		provisioningType = "thick"
+		if b.ThinProvisioned != nil && *b.ThinProvisioned {
+			provisioningType = "thin"
+		} else if b.EagerlyScrub != nil && *b.EagerlyScrub {
+			provisioningType = "eagerZeroedThick"
+		}
+		return provisioningType, b.DiskMode, b.Sharing
+	case *types.VirtualDiskSeSparseBackingInfo:
+		return "seSparse", b.DiskMode, ""
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		return "sparse", b.DiskMode, ""
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		return "rdm", b.DiskMode, b.Sharing
+	case *types.VirtualDiskFlatVer1BackingInfo:
+		return "thick", b.DiskMode, ""
+	}
+	return "", "", ""
+}
+
+// controllerBus returns the bus name used by the perf counters instances of the devices attached to the controller
+func controllerBus(controller types.BaseVirtualDevice) string {
+	switch controller.(type) {
+	case types.BaseVirtualSCSIController:
+		return "scsi"
+	case types.BaseVirtualSATAController:
+		return "sata"
+	case *types.VirtualNVMEController:
+		return "nvme"
+	case *types.VirtualIDEController:
+		return "ide"
+	}
+	return ""
+}
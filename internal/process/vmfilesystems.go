@@ -0,0 +1,39 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// createGuestFilesystemSamples adds a sample for each filesystem reported by the guest to the vm entity.
+// Filesystems are only reported when VMware Tools is running in the guest.
+func createGuestFilesystemSamples(config *config.Config, e *integration.Entity, vm *mo.VirtualMachine) {
+	if vm.Guest == nil {
+		return
+	}
+
+	for _, disk := range vm.Guest.Disk {
+		ms := e.NewMetricSet("VSphere" + sampleTypeGuestFilesystemVm + "Sample")
+
+		checkError(config.Logrus, ms.SetMetric("diskPath", disk.DiskPath, metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("capacityMiB", disk.Capacity/(1<<20), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("freeSpaceMiB", disk.FreeSpace/(1<<20), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric("usedSpaceMiB", (disk.Capacity-disk.FreeSpace)/(1<<20), metric.GAUGE))
+
+		var usedPercent float64
+		if disk.Capacity > 0 {
+			usedPercent = float64(disk.Capacity-disk.FreeSpace) / float64(disk.Capacity) * 100
+		}
+		checkError(config.Logrus, ms.SetMetric("usedPercent", usedPercent, metric.GAUGE))
+
+		// available in api v7
+		if disk.FilesystemType != "" {
+			checkError(config.Logrus, ms.SetMetric("filesystemType", disk.FilesystemType, metric.ATTRIBUTE))
+		}
+	}
+}
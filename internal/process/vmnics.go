@@ -0,0 +1,117 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/v3/integration"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/newrelic/nri-vsphere/internal/performance"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// netPerfGroup is the group of the perf counters reported for each virtual nic instance
+const netPerfGroup = "net."
+
+// createVirtualNicSamples adds a sample for each virtual nic of the vm to the vm entity
+func createVirtualNicSamples(config *config.Config, e *integration.Entity, dc *model.Datacenter, vm *mo.VirtualMachine) {
+	var perfMetrics []performance.PerfMetric
+	if config.PerfMetricsCollectionEnabled() {
+		perfMetrics = dc.GetPerfMetrics(vm.Self)
+	}
+
+	// ip addresses reported by the guest are linked to the nic by its key
+	guestNics := make(map[int32]types.GuestNicInfo)
+	if vm.Guest != nil {
+		for _, nic := range vm.Guest.Net {
+			guestNics[nic.DeviceConfigId] = nic
+		}
+	}
+
+	for _, device := range vm.Config.Hardware.Device {
+		card, ok := device.(types.BaseVirtualEthernetCard)
+		if !ok {
+			continue
+		}
+		nic := card.GetVirtualEthernetCard()
+		ms := e.NewMetricSet("VSphere" + sampleTypeNicVm + "Sample")
+
+		if nic.DeviceInfo != nil {
+			checkError(config.Logrus, ms.SetMetric("name", nic.DeviceInfo.GetDescription().Label, metric.ATTRIBUTE))
+		}
+		checkError(config.Logrus, ms.SetMetric("key", fmt.Sprintf("%d", nic.Key), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("adapterType", adapterType(device), metric.ATTRIBUTE))
+		checkError(config.Logrus, ms.SetMetric("macAddress", nic.MacAddress, metric.ATTRIBUTE))
+		if nic.Connectable != nil {
+			checkError(config.Logrus, ms.SetMetric("connected", fmt.Sprintf("%t", nic.Connectable.Connected), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("startConnected", fmt.Sprintf("%t", nic.Connectable.StartConnected), metric.ATTRIBUTE))
+		}
+
+		switch backing := nic.Backing.(type) {
+		case *types.VirtualEthernetCardNetworkBackingInfo:
+			checkError(config.Logrus, ms.SetMetric("networkName", backing.DeviceName, metric.ATTRIBUTE))
+		case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+			if pg := dc.FindDistributedPortgroup(backing.Port.SwitchUuid, backing.Port.PortgroupKey); pg != nil {
+				checkError(config.Logrus, ms.SetMetric("networkName", pg.Name, metric.ATTRIBUTE))
+			}
+			checkError(config.Logrus, ms.SetMetric("distributedPortgroupKey", backing.Port.PortgroupKey, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("distributedPortKey", backing.Port.PortKey, metric.ATTRIBUTE))
+		case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+			checkError(config.Logrus, ms.SetMetric("opaqueNetworkId", backing.OpaqueNetworkId, metric.ATTRIBUTE))
+		}
+
+		if guestNic, ok := guestNics[nic.Key]; ok {
+			var ipAddresses []string
+			// available in api v5
+			if guestNic.IpConfig != nil {
+				for _, addr := range guestNic.IpConfig.IpAddress {
+					ipAddresses = append(ipAddresses, addr.IpAddress)
+				}
+			} else {
+				ipAddresses = guestNic.IpAddress
+			}
+			checkError(config.Logrus, ms.SetMetric("ipAddresses", strings.Join(ipAddresses, "|"), metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("guestConnected", fmt.Sprintf("%t", guestNic.Connected), metric.ATTRIBUTE))
+		}
+
+		// Performance metrics of the nic instance, identified by the device key
+		instance := fmt.Sprintf("%d", nic.Key)
+		for _, perfMetric := range perfMetrics {
+			if !strings.HasPrefix(perfMetric.Counter, netPerfGroup) {
+				continue
+			}
+			if value, ok := perfMetric.Instances[instance]; ok {
+				checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, value, metric.GAUGE))
+			}
+		}
+	}
+}
+
+// adapterType returns the type of the virtual ethernet card, such as vmxnet3 or e1000
+func adapterType(device types.BaseVirtualDevice) string {
+	switch device.(type) {
+	case *types.VirtualVmxnet3:
+		return "vmxnet3"
+	case *types.VirtualVmxnet3Vrdma:
+		return "vmxnet3vrdma"
+	case *types.VirtualVmxnet2:
+		return "vmxnet2"
+	case *types.VirtualVmxnet:
+		return "vmxnet"
+	case *types.VirtualE1000e:
+		return "e1000e"
+	case *types.VirtualE1000:
+		return "e1000"
+	case *types.VirtualPCNet32:
+		return "pcnet32"
+	case *types.VirtualSriovEthernetCard:
+		return "sriov"
+	}
+	return "unknown"
+}
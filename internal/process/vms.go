@@ -10,6 +10,17 @@ import (
 
 	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
 	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	placementStatePlaced       = "placed"
+	placementStateTemplate     = "template"
+	placementStateNoPool       = "noResourcePool"
+	placementStateUnplaced     = "unplaced"
+	placementStateHostNotFound = "hostNotFound"
 )
 
 func createVirtualMachineSamples(config *config.Config) {
@@ -29,33 +40,23 @@ func createVirtualMachineSamples(config *config.Config) {
 				continue
 			}
 
-			// resourcePool Returns null if the virtual machine is a template or the session has no access to the resource pool.
-			if vm.ResourcePool == nil {
-				config.Logrus.WithField("vmName", vm.Config.Name).Debug("vm.ResourcePool is nil for this vm")
-				continue
-			}
-
-			// This property is null if the virtual machine is not running and is not assigned to run on a particular host.
-			if vm.Summary.Runtime.Host == nil {
-				config.Logrus.WithField("vmName", vm.Config.Name).Debug("vm.Summary.Runtime.Host is nil for this vm")
-				continue
-			}
-
-			// we need the host and it's parent
-			if h, ok := dc.Hosts[vm.Summary.Runtime.Host.Reference()]; !ok || ok && h.Parent == nil {
-				config.Logrus.WithField("vmName", vm.Config.Name).Debug("host not found for this vm")
-				continue
-			}
-
-			vmHost := dc.Hosts[*vm.Summary.Runtime.Host]
-			vmHostParent := *vmHost.Parent
-			hostConfigName := vmHost.Summary.Config.Name
 			vmConfigName := vm.Summary.Config.Name
 			datacenterName := dc.Datacenter.Name
 
-			entityName := hostConfigName + ":" + vmConfigName
-			if c, ok := dc.Clusters[vmHostParent]; ok {
-				entityName = c.Name + ":" + entityName
+			// templates and vms not placed on a known host are reported as well, without the host data
+			vmHost, placementState := vmPlacement(dc, vm)
+			if placementState != placementStatePlaced {
+				config.Logrus.WithField("vmName", vm.Config.Name).WithField("placementState", placementState).Debug("vm is not placed in a resource pool of a known host")
+			}
+
+			entityName := vmConfigName
+			var vmCluster *mo.ClusterComputeResource
+			if vmHost != nil {
+				entityName = vmHost.Summary.Config.Name + ":" + entityName
+				if c, ok := dc.Clusters[*vmHost.Parent]; ok {
+					vmCluster = c
+					entityName = c.Name + ":" + entityName
+				}
 			}
 			entityName = sanitizeEntityName(config, entityName, datacenterName)
 
@@ -81,11 +82,14 @@ func createVirtualMachineSamples(config *config.Config) {
 				checkError(config.Logrus, ms.SetMetric("datacenterName", datacenterName, metric.ATTRIBUTE))
 			}
 
-			if c, ok := dc.Clusters[vmHostParent]; ok {
-				checkError(config.Logrus, ms.SetMetric("clusterName", c.Name, metric.ATTRIBUTE))
+			if vmCluster != nil {
+				checkError(config.Logrus, ms.SetMetric("clusterName", vmCluster.Name, metric.ATTRIBUTE))
 			}
 
-			checkError(config.Logrus, ms.SetMetric("hypervisorHostname", hostConfigName, metric.ATTRIBUTE))
+			if vmHost != nil {
+				checkError(config.Logrus, ms.SetMetric("hypervisorHostname", vmHost.Summary.Config.Name, metric.ATTRIBUTE))
+			}
+			checkError(config.Logrus, ms.SetMetric("placementState", placementState, metric.ATTRIBUTE))
 
 			// vm
 			checkError(config.Logrus, ms.SetMetric("vmConfigName", vmConfigName, metric.ATTRIBUTE))
@@ -96,9 +100,11 @@ func createVirtualMachineSamples(config *config.Config) {
 				checkError(config.Logrus, ms.SetMetric("vmHostname", vm.Summary.Guest.HostName, metric.ATTRIBUTE))
 			}
 
-			vmResourcePool := *vm.ResourcePool
-			if resourcePool, ok := dc.GetResourcePool(vmResourcePool); ok {
-				checkError(config.Logrus, ms.SetMetric("resourcePoolName", resourcePool.Name, metric.ATTRIBUTE))
+			// resourcePool is null if the virtual machine is a template or the session has no access to the resource pool.
+			if vm.ResourcePool != nil {
+				if resourcePool, ok := dc.GetResourcePool(*vm.ResourcePool); ok {
+					checkError(config.Logrus, ms.SetMetric("resourcePoolName", resourcePool.Name, metric.ATTRIBUTE))
+				}
 			}
 
 			datastoreList := ""
@@ -149,8 +155,26 @@ func createVirtualMachineSamples(config *config.Config) {
 				}
 			}
 			checkError(config.Logrus, ms.SetMetric("cpu.allocationLimit", cpuAllocationLimit, metric.GAUGE))
+			if vm.Config.CpuAllocation != nil {
+				setResourceAllocation(config, ms, "cpu", vm.Config.CpuAllocation)
+			}
+			if vm.Config.CpuHotAddEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("cpu.hotAddEnabled", fmt.Sprintf("%t", *vm.Config.CpuHotAddEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.CpuHotRemoveEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("cpu.hotRemoveEnabled", fmt.Sprintf("%t", *vm.Config.CpuHotRemoveEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.MemoryAllocation != nil {
+				setResourceAllocation(config, ms, "mem", vm.Config.MemoryAllocation)
+				if vm.Config.MemoryAllocation.Limit != nil {
+					checkError(config.Logrus, ms.SetMetric("mem.allocationLimit", *vm.Config.MemoryAllocation.Limit, metric.GAUGE))
+				}
+			}
+			if vm.Config.MemoryHotAddEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("mem.hotAddEnabled", fmt.Sprintf("%t", *vm.Config.MemoryHotAddEnabled), metric.ATTRIBUTE))
+			}
 
-			if vmHost.Summary.Hardware != nil {
+			if vmHost != nil && vmHost.Summary.Hardware != nil {
 				CPUMhz := vmHost.Summary.Hardware.CpuMhz
 				CPUCores := vmHost.Summary.Hardware.NumCpuCores
 				OverallCpuUsage := vm.Summary.QuickStats.OverallCpuUsage
@@ -195,10 +219,41 @@ func createVirtualMachineSamples(config *config.Config) {
 				checkError(config.Logrus, ms.SetMetric("ipAddresses", ipAddressesTrimmed, metric.ATTRIBUTE))
 			}
 
+			// guest and vmware tools
+			if vm.Guest != nil {
+				checkError(config.Logrus, ms.SetMetric("guest.toolsRunningStatus", vm.Guest.ToolsRunningStatus, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("guest.toolsVersionStatus", vm.Guest.ToolsVersionStatus2, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("guest.toolsVersion", vm.Guest.ToolsVersion, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("guest.state", vm.Guest.GuestState, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("guest.family", vm.Guest.GuestFamily, metric.ATTRIBUTE))
+				checkError(config.Logrus, ms.SetMetric("guest.id", vm.Guest.GuestId, metric.ATTRIBUTE))
+				if vm.Guest.GuestOperationsReady != nil {
+					checkError(config.Logrus, ms.SetMetric("guest.operationsReady", fmt.Sprintf("%t", *vm.Guest.GuestOperationsReady), metric.ATTRIBUTE))
+				}
+			}
+			checkError(config.Logrus, ms.SetMetric("guest.heartbeatStatus", string(vm.Summary.QuickStats.GuestHeartbeatStatus), metric.ATTRIBUTE))
+
+			// vm configuration
+			checkError(config.Logrus, ms.SetMetric("hardwareVersion", vm.Config.Version, metric.ATTRIBUTE))
+			checkError(config.Logrus, ms.SetMetric("firmware", vm.Config.Firmware, metric.ATTRIBUTE))
+			if vm.Config.BootOptions != nil && vm.Config.BootOptions.EfiSecureBootEnabled != nil {
+				checkError(config.Logrus, ms.SetMetric("secureBootEnabled", fmt.Sprintf("%t", *vm.Config.BootOptions.EfiSecureBootEnabled), metric.ATTRIBUTE))
+			}
+			if vm.Config.LatencySensitivity != nil {
+				checkError(config.Logrus, ms.SetMetric("latencySensitivity", string(vm.Config.LatencySensitivity.Level), metric.ATTRIBUTE))
+			}
+			checkError(config.Logrus, ms.SetMetric("isTemplate", fmt.Sprintf("%t", vm.Config.Template), metric.ATTRIBUTE))
+			if vm.Config.FtInfo != nil {
+				checkError(config.Logrus, ms.SetMetric("ftRole", faultToleranceRole(vm.Config.FtInfo.GetFaultToleranceConfigInfo().Role), metric.ATTRIBUTE))
+			}
+
 			// vm state
 			checkError(config.Logrus, ms.SetMetric("connectionState", fmt.Sprintf("%v", vm.Runtime.ConnectionState), metric.ATTRIBUTE))
 			checkError(config.Logrus, ms.SetMetric("powerState", fmt.Sprintf("%v", vm.Runtime.PowerState), metric.ATTRIBUTE))
 
+			// Triggered alarms
+			createTriggeredAlarmSamples(config, e, dc, vm.Self)
+
 			// Tags
 			if config.TagCollectionEnabled() {
 				tagsByCategory := config.TagCollector.GetTagsByCategories(vm.Self)
@@ -215,8 +270,18 @@ func createVirtualMachineSamples(config *config.Config) {
 				for _, perfMetric := range perfMetrics {
 					checkError(config.Logrus, ms.SetMetric(perfMetricPrefix+perfMetric.Counter, perfMetric.Value, metric.GAUGE))
 				}
+				createPerfInstanceSamples(config, e, entityTypeVm, perfMetrics)
 			}
 
+			// Virtual disks
+			createVirtualDiskSamples(config, e, dc, vm)
+
+			// Virtual nics
+			createVirtualNicSamples(config, e, dc, vm)
+
+			// Guest filesystems
+			createGuestFilesystemSamples(config, e, vm)
+
 			// Snapshots
 			if vm.Snapshot != nil && vm.LayoutEx != nil && config.Args.EnableVsphereSnapshots {
 				sp := newSnapshotProcessor(config.Logrus, vm)
@@ -239,3 +304,47 @@ func createVirtualMachineSamples(config *config.Config) {
 		}
 	}
 }
+
+// setResourceAllocation adds the reservation and shares of the cpu or memory allocation of the vm.
+// The cpu limit is reported as cpu.allocationLimit regardless of the allocation being set.
+func setResourceAllocation(config *config.Config, ms *metric.Set, prefix string, allocation *types.ResourceAllocationInfo) {
+	if allocation.Reservation != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".allocationReservation", *allocation.Reservation, metric.GAUGE))
+	}
+	if allocation.Shares != nil {
+		checkError(config.Logrus, ms.SetMetric(prefix+".allocationShares", int(allocation.Shares.Shares), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(prefix+".allocationSharesLevel", string(allocation.Shares.Level), metric.ATTRIBUTE))
+	}
+}
+
+// faultToleranceRole returns the role of the vm in its fault tolerance group, the primary vm has the role 1
+func faultToleranceRole(role int32) string {
+	if role == 1 {
+		return "primary"
+	}
+	return "secondary"
+}
+
+// vmPlacement returns the host of the vm, if it is known, and the placement state of the vm
+func vmPlacement(dc *model.Datacenter, vm *mo.VirtualMachine) (*mo.HostSystem, string) {
+	var vmHost *mo.HostSystem
+	// This property is null if the virtual machine is not running and is not assigned to run on a particular host.
+	if vm.Summary.Runtime.Host != nil {
+		// we need the host and it's parent
+		if h, ok := dc.Hosts[*vm.Summary.Runtime.Host]; ok && h.Parent != nil {
+			vmHost = h
+		}
+	}
+
+	switch {
+	case vm.Config.Template:
+		return vmHost, placementStateTemplate
+	case vm.Summary.Runtime.Host == nil:
+		return vmHost, placementStateUnplaced
+	case vmHost == nil:
+		return vmHost, placementStateHostNotFound
+	case vm.ResourcePool == nil:
+		return vmHost, placementStateNoPool
+	}
+	return vmHost, placementStatePlaced
+}
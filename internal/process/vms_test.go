@@ -11,10 +11,13 @@ import (
 	"github.com/newrelic/nri-vsphere/internal/model"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func Test_createVirtualMachineSamples_HasIpAddresses(t *testing.T) {
@@ -38,6 +41,9 @@ func Test_createVirtualMachineSamples_HasIpAddresses(t *testing.T) {
 		assert.True(t, len(cfg.Datacenters[0].VirtualMachines) > 0)
 		for _, e := range cfg.Integration.Entities {
 			for _, ms := range e.Metrics {
+				if ms.Metrics["event_type"] != "VSphereVmSample" {
+					continue
+				}
 				// we just chek the presence because it might have no 'extra' ip addresses but we still add the attribute
 				assert.Contains(t, ms.Metrics, "ipAddresses")
 			}
@@ -46,6 +52,203 @@ func Test_createVirtualMachineSamples_HasIpAddresses(t *testing.T) {
 	})
 }
 
+func Test_createVirtualMachineSamples_HasGuestAttributes(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.VirtualMachines(cfg)
+
+		createVirtualMachineSamples(cfg)
+
+		// then
+		samples := samplesByEventType(cfg.Integration.Entities...)["VSphereVmSample"]
+		require.NotEmpty(t, samples)
+		for _, sample := range samples {
+			assert.NotEmpty(t, sample["guest.toolsRunningStatus"])
+			assert.Contains(t, sample, "guest.toolsVersionStatus")
+			assert.Contains(t, sample, "guest.toolsVersion")
+			assert.Contains(t, sample, "guest.state")
+			assert.Contains(t, sample, "guest.family")
+			assert.Contains(t, sample, "guest.id")
+			assert.Contains(t, sample, "guest.heartbeatStatus")
+		}
+		return nil
+	})
+}
+
+func Test_createVirtualMachineSamples_HasConfigAttributes(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.VirtualMachines(cfg)
+
+		createVirtualMachineSamples(cfg)
+
+		// then
+		samples := samplesByEventType(cfg.Integration.Entities...)["VSphereVmSample"]
+		require.NotEmpty(t, samples)
+		for _, sample := range samples {
+			assert.Regexp(t, "^vmx-[0-9]+$", sample["hardwareVersion"])
+			assert.Contains(t, sample, "firmware")
+			assert.Equal(t, "false", sample["isTemplate"])
+			assert.Contains(t, sample, "cpu.allocationReservation")
+			assert.Contains(t, sample, "cpu.allocationShares")
+			assert.Contains(t, sample, "cpu.allocationSharesLevel")
+			assert.Contains(t, sample, "mem.allocationReservation")
+			assert.Contains(t, sample, "mem.allocationLimit")
+		}
+		return nil
+	})
+}
+
+func Test_createVirtualMachineSamples_ReportsTemplates(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given a vm converted to template
+		finder := find.NewFinder(vc)
+		template, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		task, err := template.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+		require.NoError(t, template.MarkAsTemplate(ctx))
+
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.VirtualMachines(cfg)
+
+		createVirtualMachineSamples(cfg)
+
+		// then every vm is reported
+		samples := samplesByEventType(cfg.Integration.Entities...)["VSphereVmSample"]
+		assert.Len(t, samples, len(cfg.Datacenters[0].VirtualMachines))
+
+		templateSample := sampleWith(samples, "vmConfigName", "DC0_H0_VM0")
+		require.NotNil(t, templateSample)
+		assert.Equal(t, "template", templateSample["placementState"])
+		assert.Equal(t, "true", templateSample["isTemplate"])
+		assert.NotContains(t, templateSample, "resourcePoolName")
+
+		vmSample := sampleWith(samples, "vmConfigName", "DC0_H0_VM1")
+		require.NotNil(t, vmSample)
+		assert.Equal(t, "placed", vmSample["placementState"])
+		assert.Equal(t, "false", vmSample["isTemplate"])
+		return nil
+	})
+}
+
+func Test_createVirtualDiskSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.Datastores(cfg)
+		collect.VirtualMachines(cfg)
+
+		createVirtualMachineSamples(cfg)
+
+		// then every vm has a sample for its disk
+		require.NotEmpty(t, cfg.Integration.Entities)
+		for _, e := range cfg.Integration.Entities {
+			disks := samplesByEventType(e)["VSphereDiskVmSample"]
+			if assert.Len(t, disks, 1) {
+				assert.Equal(t, "thin", disks[0]["provisioningType"])
+				assert.Equal(t, "persistent", disks[0]["diskMode"])
+				assert.Equal(t, "pvscsi", disks[0]["controllerType"])
+				assert.Equal(t, "scsi0:0", disks[0]["instance"])
+				assert.Equal(t, "0", disks[0]["busNumber"])
+				assert.Equal(t, "0", disks[0]["unitNumber"])
+				assert.Equal(t, "LocalDS_0", disks[0]["datastoreName"])
+				assert.NotEmpty(t, disks[0]["fileName"])
+				assert.Contains(t, disks[0], "capacityMiB")
+			}
+		}
+		return nil
+	})
+}
+
+func Test_createVirtualNicSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		// given
+		cfg := newSimulatorConfig(ctx, t, vc)
+
+		// when
+		collect.Hosts(cfg)
+		collect.DistributedSwitches(cfg)
+		collect.VirtualMachines(cfg)
+
+		createVirtualMachineSamples(cfg)
+
+		// then every vm has a sample for its nic
+		require.NotEmpty(t, cfg.Integration.Entities)
+		for _, e := range cfg.Integration.Entities {
+			nics := samplesByEventType(e)["VSphereNicVmSample"]
+			if assert.Len(t, nics, 1) {
+				assert.Equal(t, "e1000", nics[0]["adapterType"])
+				assert.Equal(t, "DC0_DVPG0", nics[0]["networkName"])
+				assert.NotEmpty(t, nics[0]["macAddress"])
+				assert.Contains(t, nics[0], "connected")
+				assert.Contains(t, nics[0], "ipAddresses")
+			}
+		}
+		return nil
+	})
+}
+
+func Test_createGuestFilesystemSamples(t *testing.T) {
+	cfg := &config.Config{Logrus: logrus.StandardLogger()}
+	cfg.Integration, _ = integration.New("test", "dev")
+	e, err := cfg.Integration.Entity("vm", "vsphere-vm")
+	assert.NoError(t, err)
+
+	vm := &mo.VirtualMachine{Guest: &types.GuestInfo{Disk: []types.GuestDiskInfo{
+		{DiskPath: "/", Capacity: 4 << 30, FreeSpace: 1 << 30, FilesystemType: "ext4"},
+		{DiskPath: "C:\\", Capacity: 0, FreeSpace: 0},
+	}}}
+	createGuestFilesystemSamples(cfg, e, vm)
+
+	samples := samplesByEventType(e)["VSphereGuestFilesystemVmSample"]
+	require.Len(t, samples, 2)
+
+	root := sampleWith(samples, "diskPath", "/")
+	require.NotNil(t, root)
+	assert.Equal(t, float64(4096), root["capacityMiB"])
+	assert.Equal(t, float64(1024), root["freeSpaceMiB"])
+	assert.Equal(t, float64(75), root["usedPercent"])
+	assert.Equal(t, "ext4", root["filesystemType"])
+
+	empty := sampleWith(samples, "diskPath", "C:\\")
+	require.NotNil(t, empty)
+	assert.Equal(t, float64(0), empty["usedPercent"])
+	assert.NotContains(t, empty, "filesystemType")
+}
+
+func Test_virtualDiskBacking(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		backing          types.BaseVirtualDeviceBackingInfo
+		provisioningType string
+	}{
+		{&types.VirtualDiskFlatVer2BackingInfo{ThinProvisioned: &yes}, "thin"},
+		{&types.VirtualDiskFlatVer2BackingInfo{ThinProvisioned: &no, EagerlyScrub: &yes}, "eagerZeroedThick"},
+		{&types.VirtualDiskFlatVer2BackingInfo{ThinProvisioned: &no, EagerlyScrub: &no}, "thick"},
+		{&types.VirtualDiskRawDiskMappingVer1BackingInfo{}, "rdm"},
+	}
+	for _, tt := range tests {
+		provisioningType, _, _ := virtualDiskBacking(tt.backing)
+		assert.Equal(t, tt.provisioningType, provisioningType)
+	}
+}
+
 func getDatacenter(ctx context.Context, vm *view.Manager) *model.Datacenter {
 	cv, err := vm.CreateContainerView(ctx, vm.Client().ServiceContent.RootFolder, []string{"Datacenter"}, false)
 	if err != nil {
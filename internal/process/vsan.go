@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package process
+
+import (
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/v3/data/metric"
+	"github.com/newrelic/nri-vsphere/internal/config"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+const (
+	vsanPrefix     = "vsan."
+	vsanPerfPrefix = vsanPrefix + "perf."
+	// vsanHealthy is the health reported by the vSAN health service when checks pass
+	vsanHealthy = "green"
+)
+
+// setVsanClusterMetrics adds the capacity, dedupe and compression savings, health, resync and performance
+// data of a vSAN enabled cluster to its sample
+func setVsanClusterMetrics(config *config.Config, ms *metric.Set, vc *model.VsanCluster) {
+	checkError(config.Logrus, ms.SetMetric(vsanPrefix+"enabled", "true", metric.ATTRIBUTE))
+
+	if space := vc.SpaceUsage; space != nil {
+		capacity := float64(space.TotalCapacityB) / (1 << 30)
+		freeSpace := float64(space.FreeCapacityB) / (1 << 30)
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"capacityGiB", capacity, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"freeSpaceGiB", freeSpace, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"usedSpaceGiB", capacity-freeSpace, metric.GAUGE))
+		if capacity > 0 {
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"usedPercent", (capacity-freeSpace)/capacity*100, metric.GAUGE))
+		}
+
+		// breakdown of the used space
+		if overview := space.SpaceOverview; overview != nil {
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"primaryCapacityGiB", float64(overview.PrimaryCapacityB)/(1<<30), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"overheadGiB", float64(overview.OverheadB)/(1<<30), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"temporaryOverheadGiB", float64(overview.TemporaryOverheadB)/(1<<30), metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"reservedCapacityGiB", float64(overview.ReservedCapacityB)/(1<<30), metric.GAUGE))
+		}
+
+		// savings are only reported when deduplication and compression are enabled
+		if efficiency := space.EfficientCapacity; efficiency != nil && efficiency.PhysicalCapacityUsed > 0 {
+			savings := float64(efficiency.LogicalCapacityUsed-efficiency.PhysicalCapacityUsed) / (1 << 30)
+			ratio := float64(efficiency.LogicalCapacityUsed) / float64(efficiency.PhysicalCapacityUsed)
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"dedupeCompression.savingsGiB", savings, metric.GAUGE))
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"dedupeCompression.ratio", ratio, metric.GAUGE))
+		}
+	}
+
+	if health := vc.Health; health != nil {
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"health.overall", health.OverallHealth, metric.ATTRIBUTE))
+		unhealthyGroups := 0
+		for _, group := range health.Groups {
+			// group ids have the format com.vmware.vsan.health.test.<group>
+			groupName := group.GroupId[strings.LastIndex(group.GroupId, ".")+1:]
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"health."+groupName, group.GroupHealth, metric.ATTRIBUTE))
+			if group.GroupHealth != vsanHealthy {
+				unhealthyGroups++
+			}
+		}
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"health.unhealthyGroups", unhealthyGroups, metric.GAUGE))
+	}
+
+	if resync := vc.Resync; resync != nil {
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"resync.bytesRemaining", resync.TotalBytesToSync, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"resync.objectsRemaining", resync.TotalObjectsToSync, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"resync.etaSeconds", resync.TotalRecoveryETA, metric.GAUGE))
+	}
+
+	for label, value := range vc.PerfMetrics {
+		checkError(config.Logrus, ms.SetMetric(vsanPerfPrefix+label, value, metric.GAUGE))
+	}
+}
+
+// setVsanHostMetrics adds the disk groups, the physical disks health and the performance data of a vSAN host to its sample
+func setVsanHostMetrics(config *config.Config, ms *metric.Set, dc *model.Datacenter, host *mo.HostSystem) {
+	if host.Config == nil || host.Config.VsanHostConfig == nil {
+		return
+	}
+	vsanConfig := host.Config.VsanHostConfig
+	if vsanConfig.Enabled == nil || !*vsanConfig.Enabled {
+		return
+	}
+	checkError(config.Logrus, ms.SetMetric(vsanPrefix+"enabled", "true", metric.ATTRIBUTE))
+	if vsanConfig.ClusterInfo != nil {
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"nodeUuid", vsanConfig.ClusterInfo.NodeUuid, metric.ATTRIBUTE))
+	}
+
+	// each disk group has a cache disk and one or more capacity disks
+	if vsanConfig.StorageInfo != nil {
+		cacheDisks := 0
+		capacityDisks := 0
+		for _, mapping := range vsanConfig.StorageInfo.DiskMapping {
+			// the cache device of the disk group, if reported
+			if mapping.Ssd.CanonicalName != "" || mapping.Ssd.Uuid != "" {
+				cacheDisks++
+			}
+			capacityDisks += len(mapping.NonSsd)
+		}
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"diskGroups", len(vsanConfig.StorageInfo.DiskMapping), metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"cacheDisks", cacheDisks, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"capacityDisks", capacityDisks, metric.GAUGE))
+	}
+
+	if host.Parent == nil {
+		return
+	}
+	vc, ok := dc.VsanClusters[*host.Parent]
+	if !ok {
+		return
+	}
+
+	if disksHealth := vc.FindPhysicalDisksHealth(host.Summary.Config.Name); disksHealth != nil {
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"disks.health", disksHealth.OverallHealth, metric.ATTRIBUTE))
+		unhealthyDisks := 0
+		notInUse := 0
+		var usedCapacity, capacity int64
+		for _, disk := range disksHealth.Disks {
+			if disk.SummaryHealth != vsanHealthy {
+				unhealthyDisks++
+			}
+			// disks not present in the cluster directory are not used by vSAN
+			if !disk.InCmmds {
+				notInUse++
+			}
+			capacity += disk.Capacity
+			usedCapacity += disk.UsedCapacity
+		}
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"disks.unhealthy", unhealthyDisks, metric.GAUGE))
+		checkError(config.Logrus, ms.SetMetric(vsanPrefix+"disks.notInUse", notInUse, metric.GAUGE))
+		if capacity > 0 {
+			checkError(config.Logrus, ms.SetMetric(vsanPrefix+"disks.usedPercent", float64(usedCapacity)/float64(capacity)*100, metric.GAUGE))
+		}
+	}
+
+	for label, value := range vc.HostPerfMetrics[host.Self] {
+		checkError(config.Logrus, ms.SetMetric(vsanPerfPrefix+label, value, metric.GAUGE))
+	}
+}
@@ -0,0 +1,119 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/nri-vsphere/internal/collect"
+	"github.com/newrelic/nri-vsphere/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+func Test_vsanSamples(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+		cfg := newSimulatorConfig(ctx, t, vc)
+		cfg.Args.EnableVsan = true
+
+		collect.Clusters(cfg)
+		collect.Hosts(cfg)
+
+		// given a vSAN cluster with one of its hosts having a disk group
+		dc := cfg.Datacenters[0]
+		var cluster *types.ManagedObjectReference
+		for ref, c := range dc.Clusters {
+			if c.Name == "DC0_C0" {
+				cluster = &ref
+			}
+		}
+		require.NotNil(t, cluster)
+		var vsanHost string
+		for _, host := range dc.Hosts {
+			if *host.Parent != *cluster {
+				continue
+			}
+			enabled := true
+			host.Config.VsanHostConfig = &types.VsanHostConfigInfo{
+				Enabled:     &enabled,
+				ClusterInfo: &types.VsanHostConfigInfoClusterInfo{NodeUuid: "node-uuid"},
+				StorageInfo: &types.VsanHostConfigInfoStorageInfo{
+					DiskMapping: []types.VsanHostDiskMapping{
+						{
+							Ssd:    types.HostScsiDisk{ScsiLun: types.ScsiLun{CanonicalName: "naa.cache"}},
+							NonSsd: make([]types.HostScsiDisk, 2),
+						},
+						// a disk group whose cache device is not reported
+						{NonSsd: make([]types.HostScsiDisk, 1)},
+					},
+				},
+			}
+			vsanHost = host.Summary.Config.Name
+			dc.VsanClusters[*cluster] = &model.VsanCluster{
+				SpaceUsage: &vsantypes.VsanSpaceUsage{
+					TotalCapacityB: 100 << 30,
+					FreeCapacityB:  75 << 30,
+					EfficientCapacity: &vsantypes.VimVsanDataEfficiencyCapacityState{
+						LogicalCapacityUsed:  30 << 30,
+						PhysicalCapacityUsed: 20 << 30,
+					},
+				},
+				Health: &vsantypes.VsanClusterHealthSummary{
+					OverallHealth: "yellow",
+					Groups: []vsantypes.VsanClusterHealthGroup{
+						{GroupId: "com.vmware.vsan.health.test.network", GroupHealth: "green"},
+						{GroupId: "com.vmware.vsan.health.test.physicaldisks", GroupHealth: "yellow"},
+					},
+					PhysicalDisksHealth: []vsantypes.VsanPhysicalDiskHealthSummary{{
+						Hostname:      vsanHost,
+						OverallHealth: "yellow",
+						Disks: []vsantypes.VsanPhysicalDiskHealth{
+							{SummaryHealth: "green", InCmmds: true, Capacity: 100, UsedCapacity: 10},
+							{SummaryHealth: "red", InCmmds: false, Capacity: 100, UsedCapacity: 30},
+						},
+					}},
+				},
+				Resync:          &vsantypes.VsanHostVsanObjectSyncQueryResult{TotalBytesToSync: 1024, TotalObjectsToSync: 2},
+				PerfMetrics:     map[string]float64{"iopsRead": 10},
+				HostPerfMetrics: map[types.ManagedObjectReference]map[string]float64{host.Self: {"iopsRead": 5}},
+			}
+			break
+		}
+
+		// when
+		createClusterSamples(cfg)
+		createHostSamples(cfg)
+
+		// then
+		samples := samplesByEventType(cfg.Integration.Entities...)
+		clusterSample := sampleWith(samples["VSphereClusterSample"], "vsan.enabled", "true")
+		hostSample := sampleWith(samples["VSphereHostSample"], "hypervisorHostname", vsanHost)
+
+		require.NotNil(t, clusterSample)
+		assert.Equal(t, float64(100), clusterSample["vsan.capacityGiB"])
+		assert.Equal(t, float64(25), clusterSample["vsan.usedPercent"])
+		assert.Equal(t, float64(10), clusterSample["vsan.dedupeCompression.savingsGiB"])
+		assert.Equal(t, 1.5, clusterSample["vsan.dedupeCompression.ratio"])
+		assert.Equal(t, "yellow", clusterSample["vsan.health.overall"])
+		assert.Equal(t, "green", clusterSample["vsan.health.network"])
+		assert.Equal(t, float64(1), clusterSample["vsan.health.unhealthyGroups"])
+		assert.Equal(t, float64(1024), clusterSample["vsan.resync.bytesRemaining"])
+		assert.Equal(t, float64(10), clusterSample["vsan.perf.iopsRead"])
+
+		require.NotNil(t, hostSample)
+		assert.Equal(t, vsanHost, hostSample["hypervisorHostname"])
+		assert.Equal(t, "node-uuid", hostSample["vsan.nodeUuid"])
+		assert.Equal(t, float64(2), hostSample["vsan.diskGroups"])
+		assert.Equal(t, float64(1), hostSample["vsan.cacheDisks"])
+		assert.Equal(t, float64(3), hostSample["vsan.capacityDisks"])
+		assert.Equal(t, "yellow", hostSample["vsan.disks.health"])
+		assert.Equal(t, float64(1), hostSample["vsan.disks.unhealthy"])
+		assert.Equal(t, float64(1), hostSample["vsan.disks.notInUse"])
+		assert.Equal(t, float64(20), hostSample["vsan.disks.usedPercent"])
+		assert.Equal(t, float64(5), hostSample["vsan.perf.iopsRead"])
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Task is a job executed periodically by the Scheduler
+type Task struct {
+	Name     string
+	Interval time.Duration
+}
+
+// Scheduler keeps track of when each task is due, each one following its own interval
+type Scheduler struct {
+	tasks []Task
+	next  []time.Time
+}
+
+// New creates a Scheduler for the given tasks. Tasks due at the same time are returned in the order given here.
+func New(tasks ...Task) *Scheduler {
+	return &Scheduler{
+		tasks: tasks,
+		next:  make([]time.Time, len(tasks)),
+	}
+}
+
+// Run calls run with the names of the tasks due until the context is done. Every task is due in the first call.
+// Executions are not queued: if run takes longer than the interval of a task the missed executions are skipped.
+func (s *Scheduler) Run(ctx context.Context, run func(tasks []string)) {
+	for {
+		due := s.due(time.Now())
+		if len(due) > 0 {
+			run(due)
+		}
+
+		timer := time.NewTimer(time.Until(s.nextRun()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// due returns the tasks due at the given time and schedules their next execution
+func (s *Scheduler) due(now time.Time) []string {
+	var due []string
+	for i, t := range s.tasks {
+		if s.next[i].IsZero() {
+			s.next[i] = now
+		}
+		if s.next[i].After(now) {
+			continue
+		}
+		due = append(due, t.Name)
+		// next executions keep the cadence of the first one
+		for !s.next[i].After(now) {
+			s.next[i] = s.next[i].Add(t.Interval)
+		}
+	}
+	return due
+}
+
+// nextRun returns when the next task is due
+func (s *Scheduler) nextRun() time.Time {
+	var next time.Time
+	for _, n := range s.next {
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Due(t *testing.T) {
+	s := New(
+		Task{Name: "inventory", Interval: time.Minute},
+		Task{Name: "perf", Interval: 20 * time.Second},
+	)
+	start := time.Now()
+
+	// every task is due in the first call
+	assert.Equal(t, []string{"inventory", "perf"}, s.due(start))
+	assert.Equal(t, start.Add(20*time.Second), s.nextRun())
+
+	assert.Empty(t, s.due(start.Add(10*time.Second)))
+	assert.Equal(t, []string{"perf"}, s.due(start.Add(20*time.Second)))
+	assert.Equal(t, []string{"perf"}, s.due(start.Add(41*time.Second)))
+	// cadence is kept even if the previous run was delayed
+	assert.Equal(t, start.Add(time.Minute), s.nextRun())
+	assert.Equal(t, []string{"inventory", "perf"}, s.due(start.Add(time.Minute)))
+
+	// missed executions are not queued
+	assert.Equal(t, []string{"inventory", "perf"}, s.due(start.Add(5*time.Minute)))
+	assert.Equal(t, start.Add(5*time.Minute+20*time.Second), s.nextRun())
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := New(
+		Task{Name: "slow", Interval: time.Hour},
+		Task{Name: "fast", Interval: 10 * time.Millisecond},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs [][]string
+	s.Run(ctx, func(tasks []string) {
+		runs = append(runs, tasks)
+		if len(runs) == 3 {
+			cancel()
+		}
+	})
+
+	require.Len(t, runs, 3)
+	assert.Equal(t, []string{"slow", "fast"}, runs[0])
+	assert.Equal(t, []string{"fast"}, runs[1])
+	assert.Equal(t, []string{"fast"}, runs[2])
+}
@@ -76,6 +76,7 @@ func (c *Collector) BuildTagCache() error {
 
 	//clear previous cache if any
 	c.tagByIDCache = TagsByID{}
+	c.tagsByObjectCache = TagsByObject{}
 
 	ctx := context.Background()
 
@@ -164,6 +165,14 @@ func (c *Collector) FetchTagsForObjects(objectsSlice interface{}) (TagsByObject,
 		for _, o := range obs {
 			ref = append(ref, o.Self)
 		}
+	case []mo.StoragePod:
+		for _, o := range obs {
+			ref = append(ref, o.Self)
+		}
+	case []mor:
+		for _, o := range obs {
+			ref = append(ref, o)
+		}
 	default:
 		return nil, fmt.Errorf("type unknown")
 	}
@@ -176,6 +185,8 @@ func (c *Collector) FetchTagsForObjects(objectsSlice interface{}) (TagsByObject,
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect tags:%v", err)
 	}
+	// tags of objects fetched again replace the ones previously cached
+	c.clearTags(ref)
 	c.cacheTags(tagsByObject)
 
 	return tagsByObject, nil
@@ -211,6 +222,15 @@ func (c *Collector) cacheTags(tagsByObject TagsByObject) {
 	}
 }
 
+// remove the cached tags of the objects
+func (c *Collector) clearTags(ref []mo.Reference) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, r := range ref {
+		delete(c.tagsByObjectCache, r.Reference())
+	}
+}
+
 // return all tags attached to objects in ref grouped by the object reference
 func (c *Collector) getTags(ref []mo.Reference) (TagsByObject, error) {
 	ctx := context.Background()